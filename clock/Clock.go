@@ -0,0 +1,67 @@
+package clock
+
+import "errors"
+
+// ErrMismatch is returned when combining clocks of different kinds
+var ErrMismatch = errors.New("clock: cannot combine clocks of different kinds")
+
+var errMalformed = errors.New("clock: malformed encoding")
+
+// The position of one clock relative to another in the causal order
+type Ordering int
+
+const (
+    Equal Ordering = iota
+    Before
+    After
+    Concurrent
+)
+
+func (o Ordering) String() string {
+    switch o {
+    case Equal:
+        return "equal"
+    case Before:
+        return "before"
+    case After:
+        return "after"
+    case Concurrent:
+        return "concurrent"
+    }
+    return "unknown"
+}
+
+// A logical clock that can be replicated. Implementations are immutable: every operation returns a new clock.
+// Combining clocks of different kinds fails with ErrMismatch.
+type LogicalClock interface {
+    // Record a local event
+    Tick() LogicalClock
+
+    // Absorb the history of other while keeping this clock's identity. Does not record an event.
+    Merge(other LogicalClock) (LogicalClock, error)
+
+    // Compare this clock with other: Before means every event seen here was also seen by other
+    Compare(other LogicalClock) (Ordering, error)
+
+    // Fork into two clocks that share this clock's history and can tick independently
+    Spawn() (LogicalClock, LogicalClock)
+
+    // Hand this clock's identity and history over to into, returning the result. This clock must not be used again.
+    Retire(into LogicalClock) (LogicalClock, error)
+
+    // Binary encoding for the wire
+    Encode() ([]byte, error)
+}
+
+// Combine the two halves of a partial order comparison into an Ordering
+func FromLeq(leq bool, geq bool) Ordering {
+    switch {
+    case leq && geq:
+        return Equal
+    case leq:
+        return Before
+    case geq:
+        return After
+    }
+    return Concurrent
+}
@@ -0,0 +1,59 @@
+package clock
+
+import "encoding/binary"
+
+// A Lamport clock is a single counter. It orders events totally, so Compare never reports Concurrent: two clocks
+// with different counters may still be causally unrelated.
+type Lamport struct {
+    Counter uint64
+}
+
+func NewLamport() *Lamport {
+    return &Lamport{}
+}
+
+func (l *Lamport) Tick() LogicalClock {
+    return &Lamport{Counter: l.Counter + 1}
+}
+
+func (l *Lamport) Merge(other LogicalClock) (LogicalClock, error) {
+    o, ok := other.(*Lamport)
+    if !ok {
+        return nil, ErrMismatch
+    }
+    if o.Counter > l.Counter {
+        return &Lamport{Counter: o.Counter}, nil
+    }
+    return &Lamport{Counter: l.Counter}, nil
+}
+
+func (l *Lamport) Compare(other LogicalClock) (Ordering, error) {
+    o, ok := other.(*Lamport)
+    if !ok {
+        return Concurrent, ErrMismatch
+    }
+    return FromLeq(l.Counter <= o.Counter, o.Counter <= l.Counter), nil
+}
+
+// Lamport clocks carry no identity so both halves are copies
+func (l *Lamport) Spawn() (LogicalClock, LogicalClock) {
+    return &Lamport{Counter: l.Counter}, &Lamport{Counter: l.Counter}
+}
+
+func (l *Lamport) Retire(into LogicalClock) (LogicalClock, error) {
+    return into.Merge(l)
+}
+
+func (l *Lamport) Encode() ([]byte, error) {
+    var buf [binary.MaxVarintLen64]byte
+    n := binary.PutUvarint(buf[:], l.Counter)
+    return buf[:n], nil
+}
+
+func DecodeLamport(b []byte) (*Lamport, error) {
+    v, n := binary.Uvarint(b)
+    if n <= 0 || n != len(b) {
+        return nil, errMalformed
+    }
+    return &Lamport{Counter: v}, nil
+}
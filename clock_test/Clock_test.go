@@ -0,0 +1,92 @@
+package clock_test
+
+import (
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/clock"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/vector"
+	"testing"
+)
+
+var seeds = map[string]func() clock.LogicalClock{
+	"itc":     func() clock.LogicalClock { return itc.SeedStamp() },
+	"vector":  func() clock.LogicalClock { return vector.AsLogicalClock(vector.SeedClock("a")) },
+	"lamport": func() clock.LogicalClock { return clock.NewLamport() },
+}
+
+func compare(a, b clock.LogicalClock, t *testing.T) clock.Ordering {
+	o, err := a.Compare(b)
+	assert.Nil(err, t)
+	return o
+}
+
+func leq(a, b clock.LogicalClock, t *testing.T) bool {
+	o := compare(a, b, t)
+	return o == clock.Before || o == clock.Equal
+}
+
+func TestClockTick(t *testing.T) {
+	for name, seed := range seeds {
+		s := seed()
+		n := s.Tick()
+		assert.True(compare(s, s, t) == clock.Equal, t, name)
+		assert.True(compare(s, n, t) == clock.Before, t, name)
+		assert.True(compare(n, s, t) == clock.After, t, name)
+	}
+}
+
+func TestClockSpawnRetire(t *testing.T) {
+	for name, seed := range seeds {
+		a, b := seed().Tick().Spawn()
+		a = a.Tick()
+		b = b.Tick().Tick()
+
+		j, err := b.Retire(a)
+		assert.Nil(err, t, name)
+		assert.True(leq(a, j, t), t, name)
+		assert.True(leq(b, j, t), t, name)
+
+		m, err := a.Merge(b)
+		assert.Nil(err, t, name)
+		assert.True(leq(b, m, t), t, name)
+		assert.True(leq(a, m, t), t, name)
+	}
+}
+
+// Lamport clocks cannot tell concurrent events apart, the others can
+func TestClockConcurrent(t *testing.T) {
+	for name, seed := range seeds {
+		a, b := seed().Spawn()
+		a = a.Tick()
+		b = b.Tick()
+
+		if name == "lamport" {
+			assert.True(compare(a, b, t) == clock.Equal, t, name)
+		} else {
+			assert.True(compare(a, b, t) == clock.Concurrent, t, name)
+		}
+	}
+}
+
+func TestClockMismatch(t *testing.T) {
+	_, err := itc.SeedStamp().Merge(clock.NewLamport())
+	assert.True(err == clock.ErrMismatch, t)
+	_, err = clock.NewLamport().Compare(itc.SeedStamp())
+	assert.True(err == clock.ErrMismatch, t)
+}
+
+func TestClockEncode(t *testing.T) {
+	l := clock.NewLamport().Tick().Tick()
+	b, err := l.Encode()
+	assert.Nil(err, t)
+	decoded, err := clock.DecodeLamport(b)
+	assert.Nil(err, t)
+	assert.True(compare(l, decoded, t) == clock.Equal, t)
+
+	s := itc.SeedStamp().Advance()
+	b, err = s.Encode()
+	assert.Nil(err, t)
+	stamp, err := itc.DecodeStamp(b)
+	assert.Nil(err, t)
+	assert.True(compare(s, stamp, t) == clock.Equal, t)
+}
@@ -0,0 +1,125 @@
+package compare
+
+import (
+    "github.com/ziglet.io/go-itc/clock"
+    "github.com/ziglet.io/go-itc/itc"
+    "github.com/ziglet.io/go-itc/vector"
+    "strconv"
+)
+
+// A clock implementation that can be compared. Seed returns the clock of the single replica a trace starts from.
+type Clock struct {
+    Name string
+    Seed func() clock.LogicalClock
+}
+
+var (
+    ITC            = Clock{Name: "itc", Seed: func() clock.LogicalClock { return itc.SeedStamp() }}
+    VersionVectors = Clock{Name: "version-vector", Seed: func() clock.LogicalClock { return newVersionVectorReplica("r") }}
+    VectorClocks   = Clock{Name: "vector-clock", Seed: func() clock.LogicalClock { return vector.AsLogicalClock(vector.SeedClock("r")) }}
+    Lamport        = Clock{Name: "lamport", Seed: func() clock.LogicalClock { return clock.NewLamport() }}
+)
+
+// The clocks replayed by Compare, in order
+var Clocks = []Clock{ITC, VersionVectors, VectorClocks, Lamport}
+
+// Version vectors do not name replicas themselves so the replica carries its name and fork count alongside,
+// using the same naming scheme as the vector clock. Only the vector is encoded.
+type versionVectorReplica struct {
+    name  string
+    forks uint64
+    vv    vector.VersionVector
+}
+
+func newVersionVectorReplica(name string) versionVectorReplica {
+    return versionVectorReplica{name: name, vv: vector.NewVersionVector()}
+}
+
+func (r versionVectorReplica) Tick() clock.LogicalClock {
+    return versionVectorReplica{name: r.name, forks: r.forks, vv: r.vv.Increment(r.name)}
+}
+
+func (r versionVectorReplica) Merge(other clock.LogicalClock) (clock.LogicalClock, error) {
+    o, ok := other.(versionVectorReplica)
+    if !ok {
+        return nil, clock.ErrMismatch
+    }
+    return versionVectorReplica{name: r.name, forks: r.forks, vv: r.vv.Merge(o.vv)}, nil
+}
+
+func (r versionVectorReplica) Compare(other clock.LogicalClock) (clock.Ordering, error) {
+    o, ok := other.(versionVectorReplica)
+    if !ok {
+        return clock.Concurrent, clock.ErrMismatch
+    }
+    return clock.FromLeq(r.vv.Leq(o.vv), o.vv.Leq(r.vv)), nil
+}
+
+func (r versionVectorReplica) Spawn() (clock.LogicalClock, clock.LogicalClock) {
+    parent := versionVectorReplica{name: r.name, forks: r.forks + 1, vv: r.vv.Copy()}
+    child := versionVectorReplica{name: r.name + "." + strconv.FormatUint(r.forks, 10), vv: r.vv.Copy()}
+    return parent, child
+}
+
+func (r versionVectorReplica) Retire(into clock.LogicalClock) (clock.LogicalClock, error) {
+    return into.Merge(r)
+}
+
+func (r versionVectorReplica) Encode() ([]byte, error) {
+    return r.vv.Encode(), nil
+}
+
+// An ITC clock whose stamps advance with the grow policy
+func ITCWith(name string, policy itc.GrowPolicy) Clock {
+    return Clock{Name: name, Seed: func() clock.LogicalClock { return policyStamp{stamp: itc.SeedStamp(), policy: policy} }}
+}
+
+// The ITC grow policies, to compare the trees they build
+var GrowPolicies = []Clock{
+    ITCWith("itc-paper", itc.DefaultGrowPolicy),
+    ITCWith("itc-shallow", itc.ShallowGrowPolicy),
+    ITCWith("itc-largest-subtree", itc.LargestSubtreeGrowPolicy),
+}
+
+// A stamp carrying the policy it advances with
+type policyStamp struct {
+    stamp  *itc.Stamp
+    policy itc.GrowPolicy
+}
+
+func (p policyStamp) Tick() clock.LogicalClock {
+    return policyStamp{stamp: p.stamp.AdvanceWith(p.policy), policy: p.policy}
+}
+
+func (p policyStamp) Merge(other clock.LogicalClock) (clock.LogicalClock, error) {
+    o, ok := other.(policyStamp)
+    if !ok {
+        return nil, clock.ErrMismatch
+    }
+    return policyStamp{stamp: itc.NewStamp(p.stamp.Id, p.stamp.Event.Join(o.stamp.Event)), policy: p.policy}, nil
+}
+
+func (p policyStamp) Compare(other clock.LogicalClock) (clock.Ordering, error) {
+    o, ok := other.(policyStamp)
+    if !ok {
+        return clock.Concurrent, clock.ErrMismatch
+    }
+    return p.stamp.Compare(o.stamp)
+}
+
+func (p policyStamp) Spawn() (clock.LogicalClock, clock.LogicalClock) {
+    a, b := p.stamp.Fork()
+    return policyStamp{stamp: a, policy: p.policy}, policyStamp{stamp: b, policy: p.policy}
+}
+
+func (p policyStamp) Retire(into clock.LogicalClock) (clock.LogicalClock, error) {
+    o, ok := into.(policyStamp)
+    if !ok {
+        return nil, clock.ErrMismatch
+    }
+    return policyStamp{stamp: o.stamp.Join(p.stamp), policy: o.policy}, nil
+}
+
+func (p policyStamp) Encode() ([]byte, error) {
+    return p.stamp.Encode()
+}
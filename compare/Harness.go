@@ -0,0 +1,149 @@
+package compare
+
+import (
+    "fmt"
+    "github.com/ziglet.io/go-itc/clock"
+    "runtime"
+    "time"
+)
+
+// The measurements from replaying one trace through one clock
+type Result struct {
+    Clock string
+    Ops   int
+
+    // Encoded size in bytes of all replicas still live at the end of the trace
+    FinalSize int
+    // Largest encoded size of a single replica seen during the trace
+    MaxSize int
+    // Mean encoded size of the replica produced by each step
+    MeanSize float64
+
+    // Wall time and heap allocations of the replay, excluding the size measurements
+    Duration time.Duration
+    Allocs   uint64
+    Bytes    uint64
+}
+
+// Mean time per step
+func (r Result) Latency() time.Duration {
+    if r.Ops == 0 {
+        return 0
+    }
+    return r.Duration / time.Duration(r.Ops)
+}
+
+// Mean allocations per step
+func (r Result) AllocsPerOp() float64 {
+    if r.Ops == 0 {
+        return 0
+    }
+    return float64(r.Allocs) / float64(r.Ops)
+}
+
+func (r Result) String() string {
+    return fmt.Sprintf("%-16s ops=%d final=%dB max=%dB mean=%.1fB latency=%s allocs/op=%.1f",
+        r.Clock, r.Ops, r.FinalSize, r.MaxSize, r.MeanSize, r.Latency(), r.AllocsPerOp())
+}
+
+// Replay the trace through every clock in Clocks
+func Compare(trace Trace) ([]Result, error) {
+    results := make([]Result, 0, len(Clocks))
+    for _, c := range Clocks {
+        r, err := Replay(trace, c)
+        if err != nil {
+            return nil, err
+        }
+        results = append(results, r)
+    }
+    return results, nil
+}
+
+// Replay the trace through the clock c. The trace is run twice: once to measure time and allocations and once to
+// measure encoded sizes, so that encoding does not pollute the timings.
+func Replay(trace Trace, c Clock) (Result, error) {
+    result := Result{Clock: c.Name, Ops: len(trace)}
+
+    var before, after runtime.MemStats
+    runtime.ReadMemStats(&before)
+    start := time.Now()
+    if _, err := run(trace, c, nil); err != nil {
+        return result, err
+    }
+    result.Duration = time.Since(start)
+    runtime.ReadMemStats(&after)
+    result.Allocs = after.Mallocs - before.Mallocs
+    result.Bytes = after.TotalAlloc - before.TotalAlloc
+
+    total := 0
+    live, err := run(trace, c, func(l clock.LogicalClock) error {
+        b, err := l.Encode()
+        if err != nil {
+            return err
+        }
+        total += len(b)
+        if len(b) > result.MaxSize {
+            result.MaxSize = len(b)
+        }
+        return nil
+    })
+    if err != nil {
+        return result, err
+    }
+    if len(trace) > 0 {
+        result.MeanSize = float64(total) / float64(len(trace))
+    }
+
+    for _, l := range live {
+        b, err := l.Encode()
+        if err != nil {
+            return result, err
+        }
+        result.FinalSize += len(b)
+    }
+
+    return result, nil
+}
+
+// Apply the trace, calling observe with the clock produced by each step
+func run(trace Trace, c Clock, observe func(clock.LogicalClock) error) ([]clock.LogicalClock, error) {
+    live := []clock.LogicalClock{c.Seed()}
+
+    for i, op := range trace {
+        if op.Replica < 0 || op.Replica >= len(live) {
+            return nil, fmt.Errorf("compare: step %d %s: no replica %d", i, op, op.Replica)
+        }
+
+        switch op.Kind {
+        case Fork:
+            a, b := live[op.Replica].Spawn()
+            live[op.Replica] = a
+            live = append(live, b)
+        case Event:
+            live[op.Replica] = live[op.Replica].Tick()
+        case Join:
+            if op.Other < 0 || op.Other >= len(live) || op.Other == op.Replica {
+                return nil, fmt.Errorf("compare: step %d %s: cannot join replica %d", i, op, op.Other)
+            }
+            joined, err := live[op.Other].Retire(live[op.Replica])
+            if err != nil {
+                return nil, fmt.Errorf("compare: step %d %s: %v", i, op, err)
+            }
+            live[op.Replica] = joined
+            live = append(live[:op.Other], live[op.Other+1:]...)
+            if op.Other < op.Replica {
+                op.Replica--
+            }
+        default:
+            return nil, fmt.Errorf("compare: step %d: unknown op %s", i, op)
+        }
+
+        if observe != nil {
+            if err := observe(live[op.Replica]); err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    return live, nil
+}
@@ -0,0 +1,89 @@
+package compare
+
+import (
+    "fmt"
+    "math/rand"
+)
+
+type OpKind int
+
+const (
+    // Fork the replica, the new replica is appended to the live set
+    Fork OpKind = iota
+    // Record an event at the replica
+    Event
+    // Join Other into Replica, Other leaves the live set
+    Join
+)
+
+// A single step of a fork-event-join trace. Replica and Other index the live replicas at the time of the step.
+type Op struct {
+    Kind    OpKind
+    Replica int
+    Other   int
+}
+
+// A trace starts from a single seed replica
+type Trace []Op
+
+func (op Op) String() string {
+    switch op.Kind {
+    case Fork:
+        return fmt.Sprintf("fork(%d)", op.Replica)
+    case Event:
+        return fmt.Sprintf("event(%d)", op.Replica)
+    case Join:
+        return fmt.Sprintf("join(%d,%d)", op.Replica, op.Other)
+    }
+    return fmt.Sprintf("unknown(%d)", op.Kind)
+}
+
+// Generate a random but valid trace of n steps that never has more than maxReplicas live replicas.
+// Roughly 60% of the steps are events and the rest are evenly split between forks and joins.
+func RandomTrace(rng *rand.Rand, n int, maxReplicas int) Trace {
+    trace := make(Trace, 0, n)
+    live := 1
+
+    for len(trace) < n {
+        r := rng.Intn(10)
+        switch {
+        case r < 2 && live < maxReplicas:
+            trace = append(trace, Op{Kind: Fork, Replica: rng.Intn(live)})
+            live++
+        case r < 4 && live > 1:
+            a := rng.Intn(live)
+            b := rng.Intn(live - 1)
+            if b >= a {
+                b++
+            }
+            trace = append(trace, Op{Kind: Join, Replica: a, Other: b})
+            live--
+        default:
+            trace = append(trace, Op{Kind: Event, Replica: rng.Intn(live)})
+        }
+    }
+
+    return trace
+}
+
+// Generate a churn trace: fan out to width replicas, record events on each, join them all back and repeat.
+// Every round introduces fresh replicas, which is the workload where vectors keep growing.
+func ChurnTrace(rounds int, width int, events int) Trace {
+    var trace Trace
+
+    for round := 0; round < rounds; round++ {
+        for i := 1; i < width; i++ {
+            trace = append(trace, Op{Kind: Fork, Replica: i - 1})
+        }
+        for i := 0; i < width; i++ {
+            for e := 0; e < events; e++ {
+                trace = append(trace, Op{Kind: Event, Replica: i})
+            }
+        }
+        for i := width - 1; i > 0; i-- {
+            trace = append(trace, Op{Kind: Join, Replica: 0, Other: i})
+        }
+    }
+
+    return trace
+}
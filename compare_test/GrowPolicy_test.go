@@ -0,0 +1,51 @@
+package compare_test
+
+import (
+	"fmt"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/compare"
+	"math/rand"
+	"testing"
+)
+
+// The workloads the grow policies are compared on
+var workloads = []struct {
+	name  string
+	trace compare.Trace
+}{
+	{"random", compare.RandomTrace(rand.New(rand.NewSource(1)), 2000, 16)},
+	{"wide", compare.RandomTrace(rand.New(rand.NewSource(2)), 2000, 64)},
+	{"churn", compare.ChurnTrace(20, 8, 5)},
+}
+
+func TestGrowPolicies(t *testing.T) {
+	for _, w := range workloads {
+		for _, c := range compare.GrowPolicies {
+			r, err := compare.Replay(w.trace, c)
+			assert.Nil(err, t)
+			fmt.Println(w.name, r)
+			assert.True(r.FinalSize > 0, t)
+		}
+	}
+}
+
+// Reports the encoded sizes each policy leaves the stamps at, alongside the usual timings
+func BenchmarkGrowPolicy(b *testing.B) {
+	for _, w := range workloads {
+		for _, c := range compare.GrowPolicies {
+			b.Run(w.name+"/"+c.Name, func(b *testing.B) {
+				b.ReportAllocs()
+				var r compare.Result
+				for i := 0; i < b.N; i++ {
+					var err error
+					if r, err = compare.Replay(w.trace, c); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(r.FinalSize), "final-B")
+				b.ReportMetric(float64(r.MaxSize), "max-B")
+				b.ReportMetric(r.MeanSize, "mean-B")
+			})
+		}
+	}
+}
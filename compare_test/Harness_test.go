@@ -0,0 +1,84 @@
+package compare_test
+
+import (
+	"fmt"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/compare"
+	"math/rand"
+	"testing"
+)
+
+func TestReplayRejectsInvalidTrace(t *testing.T) {
+	trace := compare.Trace{{Kind: compare.Join, Replica: 0, Other: 1}}
+	_, err := compare.Replay(trace, compare.ITC)
+	assert.Err(err, t)
+}
+
+func TestReplayForkJoin(t *testing.T) {
+	trace := compare.Trace{
+		{Kind: compare.Event, Replica: 0},
+		{Kind: compare.Fork, Replica: 0},
+		{Kind: compare.Event, Replica: 0},
+		{Kind: compare.Event, Replica: 1},
+		{Kind: compare.Join, Replica: 0, Other: 1},
+	}
+
+	results, err := compare.Compare(trace)
+	assert.Nil(err, t)
+	assert.True(len(results) == len(compare.Clocks), t)
+	for _, r := range results {
+		fmt.Println(r)
+		assert.True(r.Ops == len(trace), t)
+		assert.True(r.FinalSize > 0, t)
+		assert.True(r.MaxSize >= r.FinalSize, t)
+	}
+}
+
+// Churning replicas is where ITC should win: every round leaves its retired replicas in the vectors
+func TestChurnSize(t *testing.T) {
+	results, err := compare.Compare(compare.ChurnTrace(10, 8, 3))
+	assert.Nil(err, t)
+
+	sizes := map[string]int{}
+	for _, r := range results {
+		fmt.Println(r)
+		sizes[r.Clock] = r.FinalSize
+	}
+
+	assert.True(sizes[compare.ITC.Name] < sizes[compare.VersionVectors.Name], t)
+	assert.True(sizes[compare.ITC.Name] < sizes[compare.VectorClocks.Name], t)
+}
+
+func TestRandomTrace(t *testing.T) {
+	trace := compare.RandomTrace(rand.New(rand.NewSource(1)), 2000, 16)
+	assert.True(len(trace) == 2000, t)
+
+	results, err := compare.Compare(trace)
+	assert.Nil(err, t)
+	for _, r := range results {
+		fmt.Println(r)
+	}
+}
+
+func benchmarkReplay(b *testing.B, clock compare.Clock, trace compare.Trace) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := compare.Replay(trace, clock); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReplayRandom(b *testing.B) {
+	trace := compare.RandomTrace(rand.New(rand.NewSource(1)), 1000, 16)
+	for _, c := range compare.Clocks {
+		b.Run(c.Name, func(b *testing.B) { benchmarkReplay(b, c, trace) })
+	}
+}
+
+func BenchmarkReplayChurn(b *testing.B) {
+	trace := compare.ChurnTrace(10, 8, 3)
+	for _, c := range compare.Clocks {
+		b.Run(c.Name, func(b *testing.B) { benchmarkReplay(b, c, trace) })
+	}
+}
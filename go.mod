@@ -1,6 +1,6 @@
 module github.com/ziglet.io/go-itc
 
-go 1.12
+go 1.18
 
 require (
 	github.com/gogo/protobuf v1.2.1
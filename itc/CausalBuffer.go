@@ -0,0 +1,282 @@
+package itc
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+var (
+    // ErrBufferFull is returned by Push when the buffer already holds BufferOptions.MaxBuffered messages
+    ErrBufferFull = errors.New("itc: causal buffer full")
+    // ErrBufferClosed is returned by Push after Close
+    ErrBufferClosed = errors.New("itc: causal buffer closed")
+)
+
+// A message for causal broadcast. Deps is the sender's stamp peeked before sending and Stamp the one produced by Send:
+// the message may be delivered once everything in Deps has been delivered.
+type Message[T any] struct {
+    Deps    *Stamp
+    Stamp   *Stamp
+    Payload T
+}
+
+// Build a message from the sender's stamp. Returns the message and the sender's advanced stamp.
+func NewMessage[T any](stamp *Stamp, payload T) (Message[T], *Stamp) {
+    deps, _ := stamp.Peek()
+    sent, next := stamp.Send()
+
+    return Message[T]{Deps: deps, Stamp: sent, Payload: payload}, next
+}
+
+// Join a delivered message into the receiver's stamp. Unlike Stamp.Receive no receive event is recorded: it would
+// never be broadcast, yet every later message from the receiver would depend on it and wait forever in the buffers of
+// the other replicas.
+func Receive[T any](stamp *Stamp, message Message[T]) *Stamp {
+    return stamp.Join(message.Stamp)
+}
+
+// A message that has waited longer than BufferOptions.Timeout, with the ranges of events it is still waiting for.
+// The message stays buffered in case they arrive.
+type Gap[T any] struct {
+    Message Message[T]
+    Missing []MissingRange
+    Waiting time.Duration
+}
+
+type BufferOptions struct {
+    // Most messages held waiting for their predecessors, 0 for no limit
+    MaxBuffered int
+    // Most messages released but not yet received from Deliveries, 0 for no limit. Push blocks while the queue is full.
+    MaxReady int
+    // How long a message may wait before it is reported as a gap, 0 to never report
+    Timeout time.Duration
+}
+
+// The shortest interval at which waiting messages are checked against the timeout
+const minGapCheck = time.Millisecond
+
+// A CausalBuffer holds incoming messages until all of their causal predecessors have been delivered and then releases
+// them in causal order on the Deliveries channel. Messages that are already delivered are dropped. With
+// BufferOptions.MaxReady a slow reader holds back the buffer: deliverable messages wait with the others and Push
+// blocks until the reader catches up.
+type CausalBuffer[T any] struct {
+    mu        sync.Mutex
+    space     *sync.Cond
+    options   BufferOptions
+    gapCheck  time.Duration
+    delivered *Event
+    pending   []pendingMessage[T]
+    ready     []Message[T]
+    gapsReady []Gap[T]
+
+    wake      chan struct{}
+    done      chan struct{}
+    closeOnce sync.Once
+    out       chan Message[T]
+    gaps      chan Gap[T]
+}
+
+type pendingMessage[T any] struct {
+    message  Message[T]
+    arrived  time.Time
+    reported bool
+}
+
+// Create a buffer for a replica that has already delivered everything in delivered
+func NewCausalBuffer[T any](delivered *Event, options BufferOptions) *CausalBuffer[T] {
+    b := &CausalBuffer[T]{
+        options:   options,
+        delivered: delivered,
+        wake:      make(chan struct{}, 1),
+        done:      make(chan struct{}),
+        out:       make(chan Message[T]),
+        gaps:      make(chan Gap[T]),
+    }
+    b.space = sync.NewCond(&b.mu)
+    if options.Timeout > 0 {
+        b.gapCheck = options.Timeout / 4
+        if b.gapCheck < minGapCheck {
+            b.gapCheck = minGapCheck
+        }
+    }
+    go b.run()
+
+    return b
+}
+
+// Messages in causal order. Closed by Close.
+func (b *CausalBuffer[T]) Deliveries() <-chan Message[T] {
+    return b.out
+}
+
+// Messages that have waited longer than the timeout. Closed by Close.
+func (b *CausalBuffer[T]) Gaps() <-chan Gap[T] {
+    return b.gaps
+}
+
+// Everything released so far
+func (b *CausalBuffer[T]) Delivered() *Event {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    return b.delivered
+}
+
+// The number of messages waiting for their predecessors
+func (b *CausalBuffer[T]) Buffered() int {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    return len(b.pending)
+}
+
+// Accept a message, releasing it and any messages waiting on it that are now deliverable. Blocks while the ready queue
+// is full.
+func (b *CausalBuffer[T]) Push(message Message[T]) error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    for {
+        select {
+        case <-b.done:
+            return ErrBufferClosed
+        default:
+        }
+        if !b.readyFull() {
+            break
+        }
+        b.space.Wait()
+    }
+
+    if message.Stamp.Event.Leq(b.delivered) {
+        return nil
+    }
+    if b.options.MaxBuffered > 0 && len(b.pending) >= b.options.MaxBuffered && !message.Deps.Event.Leq(b.delivered) {
+        return ErrBufferFull
+    }
+
+    b.pending = append(b.pending, pendingMessage[T]{message: message, arrived: time.Now()})
+    b.release()
+
+    return nil
+}
+
+// Record history delivered outside the buffer, such as the replica's own messages
+func (b *CausalBuffer[T]) Observe(event *Event) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.delivered = b.delivered.Join(event)
+    b.release()
+}
+
+// Stop the buffer and close its channels. Buffered messages are dropped.
+func (b *CausalBuffer[T]) Close() {
+    b.closeOnce.Do(func() {
+        close(b.done)
+
+        b.mu.Lock()
+        b.space.Broadcast()
+        b.mu.Unlock()
+    })
+}
+
+// True if no more messages may be released until the reader receives some. Called with the lock held.
+func (b *CausalBuffer[T]) readyFull() bool {
+    return b.options.MaxReady > 0 && len(b.ready) >= b.options.MaxReady
+}
+
+// Move every deliverable message to the ready queue. Called with the lock held.
+func (b *CausalBuffer[T]) release() {
+    released := false
+
+    for progress := true; progress; {
+        progress = false
+        kept := b.pending[:0]
+        for _, p := range b.pending {
+            switch {
+            case p.message.Stamp.Event.Leq(b.delivered):
+                // Delivered through another message or Observe
+            case p.message.Deps.Event.Leq(b.delivered) && !b.readyFull():
+                b.delivered = b.delivered.Join(p.message.Stamp.Event)
+                b.ready = append(b.ready, p.message)
+                progress = true
+                released = true
+            default:
+                kept = append(kept, p)
+            }
+        }
+        b.pending = kept
+    }
+
+    if released {
+        select {
+        case b.wake <- struct{}{}:
+        default:
+        }
+    }
+}
+
+// Report messages that have waited too long. Called with the lock held.
+func (b *CausalBuffer[T]) expire(now time.Time) {
+    for i := range b.pending {
+        p := &b.pending[i]
+        waiting := now.Sub(p.arrived)
+        if p.reported || waiting < b.options.Timeout {
+            continue
+        }
+        p.reported = true
+        b.gapsReady = append(b.gapsReady, Gap[T]{
+            Message: p.message,
+            Missing: b.delivered.Missing(p.message.Deps.Event).All(),
+            Waiting: waiting,
+        })
+    }
+}
+
+func (b *CausalBuffer[T]) run() {
+    var tick <-chan time.Time
+    if b.gapCheck > 0 {
+        ticker := time.NewTicker(b.gapCheck)
+        defer ticker.Stop()
+        tick = ticker.C
+    }
+
+    for {
+        b.mu.Lock()
+        var out chan Message[T]
+        var next Message[T]
+        if len(b.ready) > 0 {
+            out, next = b.out, b.ready[0]
+        }
+        var gaps chan Gap[T]
+        var gap Gap[T]
+        if len(b.gapsReady) > 0 {
+            gaps, gap = b.gaps, b.gapsReady[0]
+        }
+        b.mu.Unlock()
+
+        select {
+        case out <- next:
+            b.mu.Lock()
+            b.ready = b.ready[1:]
+            b.release()
+            b.space.Broadcast()
+            b.mu.Unlock()
+        case gaps <- gap:
+            b.mu.Lock()
+            b.gapsReady = b.gapsReady[1:]
+            b.mu.Unlock()
+        case <-b.wake:
+        case now := <-tick:
+            b.mu.Lock()
+            b.expire(now)
+            b.mu.Unlock()
+        case <-b.done:
+            close(b.out)
+            close(b.gaps)
+            return
+        }
+    }
+}
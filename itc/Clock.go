@@ -0,0 +1,60 @@
+package itc
+
+import (
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/clock"
+)
+
+// Stamps are logical clocks
+var _ clock.LogicalClock = (*Stamp)(nil)
+
+// Record a local event, same as Advance
+func (stamp *Stamp) Tick() clock.LogicalClock {
+    return stamp.Advance()
+}
+
+// Join the other stamp's event tree into this one, keeping this stamp's Id
+func (stamp *Stamp) Merge(other clock.LogicalClock) (clock.LogicalClock, error) {
+    o, ok := other.(*Stamp)
+    if !ok {
+        return nil, clock.ErrMismatch
+    }
+    return NewStamp(stamp.Id, stamp.Event.Join(o.Event)), nil
+}
+
+// Compare the event trees of the two stamps
+func (stamp *Stamp) Compare(other clock.LogicalClock) (clock.Ordering, error) {
+    o, ok := other.(*Stamp)
+    if !ok {
+        return clock.Concurrent, clock.ErrMismatch
+    }
+    return CompareEvents(stamp.Event, o.Event), nil
+}
+
+// Same as Fork
+func (stamp *Stamp) Spawn() (clock.LogicalClock, clock.LogicalClock) {
+    s1, s2 := stamp.Fork()
+    return s1, s2
+}
+
+// Join this stamp into the other, returning its Id to the survivor
+func (stamp *Stamp) Retire(into clock.LogicalClock) (clock.LogicalClock, error) {
+    o, ok := into.(*Stamp)
+    if !ok {
+        return nil, clock.ErrMismatch
+    }
+    return o.Join(stamp), nil
+}
+
+// Encode the stamp as a protobuf
+func (stamp *Stamp) Encode() ([]byte, error) {
+    return proto.Marshal(stamp)
+}
+
+func DecodeStamp(b []byte) (*Stamp, error) {
+    stamp := &Stamp{}
+    if err := proto.Unmarshal(b, stamp); err != nil {
+        return nil, err
+    }
+    return stamp, nil
+}
@@ -0,0 +1,86 @@
+package itc
+
+import "github.com/ziglet.io/go-itc/clock"
+
+// Comparisons that pass the lifted offsets down instead of lifting copies of the trees, so they allocate nothing
+// however large the trees are. Identical subtrees, as shared by forks or an Interner, are answered without walking
+// them. The events must be normalised.
+
+// Same as Event.Leq
+func LeqEvents(event1 *Event, event2 *Event) bool {
+    return leqAt(event1, 0, event2, 0)
+}
+
+// Leq of the events lifted by o1 and o2
+func leqAt(event1 *Event, o1 uint32, event2 *Event, o2 uint32) bool {
+    // The same subtree is below itself lifted at least as far
+    if event1 == event2 {
+        return o1 <= o2
+    }
+
+    n1 := event1.Value + o1
+    n2 := event2.Value + o2
+
+    // Cases 1 and 2: leq(n1,n2) and leq(n1,(n2,l2,r2)) -> n1 <= n2
+    if event1.IsLeaf || n1 > n2 {
+        return n1 <= n2
+    }
+
+    // Case 3: leq((n1,l1,r1),n2) -> leq(l1.Lift(n1),n2) and leq(r1.Lift(n1),n2)
+    if event2.IsLeaf {
+        return leqAt(event1.Left, n1, event2, o2) && leqAt(event1.Right, n1, event2, o2)
+    }
+
+    // Case 4: leq((n1,l1,r1),(n2,l2,r2)) -> leq(l1.Lift(n1),l2.Lift(n2)) and leq(r1.Lift(n1),r2.Lift(n2))
+    return leqAt(event1.Left, n1, event2.Left, n2) && leqAt(event1.Right, n1, event2.Right, n2)
+}
+
+// How the first event orders against the second, both directions of Leq in a single walk
+func CompareEvents(event1 *Event, event2 *Event) clock.Ordering {
+    leq, geq := compareAt(event1, 0, event2, 0, true, true)
+    return clock.FromLeq(leq, geq)
+}
+
+// Narrow down whether the events lifted by o1 and o2 are leq and geq, stopping once neither can hold
+func compareAt(event1 *Event, o1 uint32, event2 *Event, o2 uint32, leq bool, geq bool) (bool, bool) {
+    if event1 == event2 {
+        return leq && o1 <= o2, geq && o2 <= o1
+    }
+
+    n1 := event1.Value + o1
+    n2 := event2.Value + o2
+    leq = leq && n1 <= n2
+    geq = geq && n2 <= n1
+
+    // Over a leaf the root decides: a normalised tree is nowhere below its root
+    if event1.IsLeaf && (event2.IsLeaf || !geq) || event2.IsLeaf && !leq || !leq && !geq {
+        return leq, geq
+    }
+
+    // A leaf is compared against both branches of the other side as it is
+    l1, r1, b1 := event1, event1, o1
+    if !event1.IsLeaf {
+        l1, r1, b1 = event1.Left, event1.Right, n1
+    }
+    l2, r2, b2 := event2, event2, o2
+    if !event2.IsLeaf {
+        l2, r2, b2 = event2.Left, event2.Right, n2
+    }
+
+    leq, geq = compareAt(l1, b1, l2, b2, leq, geq)
+    if !leq && !geq {
+        return leq, geq
+    }
+    return compareAt(r1, b1, r2, b2, leq, geq)
+}
+
+// True if the events are the same tree, which for normalised events is the same clock
+func EqualEvents(event1 *Event, event2 *Event) bool {
+    if event1 == event2 {
+        return true
+    }
+    if event1.IsLeaf != event2.IsLeaf || event1.Value != event2.Value {
+        return false
+    }
+    return event1.IsLeaf || EqualEvents(event1.Left, event2.Left) && EqualEvents(event1.Right, event2.Right)
+}
@@ -0,0 +1,165 @@
+package itc
+
+import (
+    "math/big"
+)
+
+// The number of separate runs of the interval the id owns. An id owning one contiguous region has 1 fragment, however
+// deep its tree; repeated forks and joins leave ids scattered over many fragments, and the event trees recorded under
+// them get as deep as the fragments are small.
+func (id *Id) Fragments() int {
+    n, _, _ := id.fragments()
+    return n
+}
+
+// The number of runs and whether the leftmost and rightmost points of the region are owned
+func (id *Id) fragments() (int, bool, bool) {
+    if id.IsLeaf {
+        if id.Value == 0 {
+            return 0, false, false
+        }
+        return 1, true, true
+    }
+
+    nl, firstl, lastl := id.Left.fragments()
+    nr, firstr, lastr := id.Right.fragments()
+
+    n := nl + nr
+    if lastl && firstr {
+        n--
+    }
+
+    return n, firstl, lastr
+}
+
+// The depth of the id tree
+func (id *Id) Depth() int {
+    if id.IsLeaf {
+        return 0
+    }
+    l, r := id.Left.Depth(), id.Right.Depth()
+    if l > r {
+        return l + 1
+    }
+    return r + 1
+}
+
+// Reassign the ids of two stamps so each owns as contiguous a region as possible, for two replicas that meet.
+//
+// The union of both ids is cut in two at the point where the part to its left has the size of one stamp's id, so both
+// keep the share of the interval they had. The cut giving that share to either stamp is taken, preferring fewer
+// fragments in total, then shallower ids, then moving less of the interval; the ids are left as they are if neither
+// cut has fewer fragments. Both stamps end with the joined event tree, normalised.
+//
+// Fails with ErrOverlappingIds if the ids overlap.
+func Defragment(s1, s2 *Stamp) (*Stamp, *Stamp, error) {
+    if s1.Id.Overlaps(s2.Id) {
+        return nil, nil, ErrOverlappingIds
+    }
+
+    event := s1.Event.Join(s2.Event).Norm()
+    union := s1.Id.Sum(s2.Id)
+    depth := union.Depth()
+    if d := s1.Id.Depth(); d > depth {
+        depth = d
+    }
+    if d := s2.Id.Depth(); d > depth {
+        depth = d
+    }
+
+    best1, best2 := s1.Id, s2.Id
+    bestFragments := best1.Fragments() + best2.Fragments()
+    bestDepth, bestKept := maxDepth(best1, best2), (*big.Int)(nil)
+
+    consider := func(i1, i2 *Id) {
+        fragments := i1.Fragments() + i2.Fragments()
+        d := maxDepth(i1, i2)
+        kept := new(big.Int).Add(measure(intersect(s1.Id, i1), depth), measure(intersect(s2.Id, i2), depth))
+
+        better := fragments < bestFragments
+        if fragments == bestFragments && bestKept != nil {
+            better = d < bestDepth || d == bestDepth && kept.Cmp(bestKept) > 0
+        }
+        if better {
+            best1, best2 = i1, i2
+            bestFragments, bestDepth, bestKept = fragments, d, kept
+        }
+    }
+
+    unit := new(big.Int).Lsh(big.NewInt(1), uint(depth))
+
+    left, right, _ := union.takeLeft(measure(s1.Id, depth), unit)
+    consider(left, right)
+
+    left, right, _ = union.takeLeft(measure(s2.Id, depth), unit)
+    consider(right, left)
+
+    return NewStamp(best1, event), NewStamp(best2, event), nil
+}
+
+// The size of the region of the id in units of 2^-depth of the interval, depth being at least the depth of the id
+func measure(id *Id, depth int) *big.Int {
+    if id.IsLeaf {
+        if id.Value == 0 {
+            return new(big.Int)
+        }
+        return new(big.Int).Lsh(big.NewInt(1), uint(depth))
+    }
+    return new(big.Int).Add(measure(id.Left, depth-1), measure(id.Right, depth-1))
+}
+
+// Split the id into the leftmost part of its region of size need and the rest, unit being the size of the whole
+// subinterval the id describes. Returns what is left of need.
+func (id *Id) takeLeft(need *big.Int, unit *big.Int) (*Id, *Id, *big.Int) {
+    if need.Sign() == 0 {
+        return NewId(0), id, need
+    }
+
+    if id.IsLeaf {
+        if id.Value == 0 {
+            return NewId(0), NewId(0), need
+        }
+        if need.Cmp(unit) >= 0 {
+            return NewId(1), NewId(0), new(big.Int).Sub(need, unit)
+        }
+        id = &Id{IsLeaf: false, Left: NewId(1), Right: NewId(1)}
+    }
+
+    half := new(big.Int).Rsh(unit, 1)
+    ll, lr, rest := id.Left.takeLeft(need, half)
+    rl, rr, rest := id.Right.takeLeft(rest, half)
+
+    left := &Id{IsLeaf: false, Left: ll, Right: rl}
+    right := &Id{IsLeaf: false, Left: lr, Right: rr}
+
+    return left.Norm(), right.Norm(), rest
+}
+
+// The normalised id owning the region both ids own
+func intersect(id1, id2 *Id) *Id {
+    if id1.IsLeaf {
+        if id1.Value == 0 {
+            return NewId(0)
+        }
+        return id2
+    }
+    if id2.IsLeaf {
+        return intersect(id2, id1)
+    }
+
+    i := &Id{
+        IsLeaf: false,
+        Left: intersect(id1.Left, id2.Left),
+        Right: intersect(id1.Right, id2.Right),
+    }
+
+    return i.Norm()
+}
+
+func maxDepth(id1, id2 *Id) int {
+    d1, d2 := id1.Depth(), id2.Depth()
+    if d1 > d2 {
+        return d1
+    }
+    return d2
+}
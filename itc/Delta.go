@@ -0,0 +1,38 @@
+package itc
+
+// Produce the part of the event tree that has advanced past base, for incremental sync. The result d satisfies
+// base.Join(d) = base.Join(event). Every subtree of event that base already dominates is collapsed into a single leaf
+// carrying the subtree's minimum, so a peer that is only slightly behind receives a small tree.
+func (event *Event) Delta(base *Event) *Event {
+    // Case 1: event <= base -> min(event)
+    if event.Leq(base) {
+        return event.Min()
+    }
+
+    // Case 2: delta(n,base) -> n
+    if event.IsLeaf {
+        return event.Copy()
+    }
+
+    // Case 3: delta((n,l,r),base) -> norm((0,delta(l.Lift(n),bl),delta(r.Lift(n),br))) where bl and br are the lifted
+    // branches of base, or base itself when it is a leaf
+    bl, br := base, base
+    if !base.IsLeaf {
+        bl = base.Left.Lift(base.Value)
+        br = base.Right.Lift(base.Value)
+    }
+
+    e := &Event{
+        IsLeaf: false,
+        Value: 0,
+        Left: event.Left.Lift(event.Value).Delta(bl),
+        Right: event.Right.Lift(event.Value).Delta(br),
+    }
+
+    return e.Norm()
+}
+
+// Merge a delta produced by Delta back into the event tree
+func (event *Event) ApplyDelta(delta *Event) *Event {
+    return event.Join(delta)
+}
@@ -0,0 +1,91 @@
+package itc
+
+import (
+    "fmt"
+    "github.com/gogo/protobuf/proto"
+)
+
+// A Dot uniquely identifies the event created by one Advance: an interval of the id space the event raised, and the
+// value it raised the interval to. Only the owner of a point raises the event tree there, so a causal history
+// includes the event exactly when it has reached the counter over the interval.
+type Dot struct {
+    // An Id owning a single interval, a path of 0s down to one 1
+    Interval *Id
+    Counter  uint32
+}
+
+// Advance and report the dot of the new event. An anonymous stamp owns no part of the id space and records no event: it
+// comes back unchanged with the zero Dot, which no history contains.
+func (stamp *Stamp) AdvanceWithDot() (*Stamp, Dot) {
+    if stamp.Id.IsEmpty() {
+        return stamp.Copy(), Dot{}
+    }
+
+    next := stamp.Advance()
+    interval, counter, _ := dotOf(stamp.Id, stamp.Event, next.Event)
+
+    return next, Dot{Interval: interval, Counter: counter}
+}
+
+// True if the causal history includes the event identified by the dot. Nothing contains the zero Dot.
+func (event *Event) ContainsDot(dot Dot) bool {
+    if dot.IsZero() {
+        return false
+    }
+    n, ok := event.MinOver(dot.Interval)
+    return ok && n >= dot.Counter
+}
+
+// True for the Dot of no event, as reported for an anonymous stamp
+func (dot Dot) IsZero() bool {
+    return dot.Interval == nil
+}
+
+func (dot Dot) Equal(other Dot) bool {
+    return dot.Counter == other.Counter && proto.Equal(dot.Interval, other.Interval)
+}
+
+// Print a pretty version as interval@counter
+func (dot Dot) Print() string {
+    if dot.IsZero() {
+        return fmt.Sprintf("0@%d", dot.Counter)
+    }
+    return fmt.Sprintf("%s@%d", dot.Interval.Print(), dot.Counter)
+}
+
+// Find the leftmost interval owned by id over which before and after are both flat and after is higher. The events
+// are walked with absolute values: a leaf stands for itself on both sides when the other trees go deeper.
+func dotOf(id *Id, before *Event, after *Event) (*Id, uint32, bool) {
+    if id.IsLeaf && id.Value == 0 {
+        return nil, 0, false
+    }
+
+    if id.IsLeaf && before.IsLeaf && after.IsLeaf {
+        if after.Value > before.Value {
+            return NewId(1), after.Value, true
+        }
+        return nil, 0, false
+    }
+
+    il, ir := id, id
+    if !id.IsLeaf {
+        il, ir = id.Left, id.Right
+    }
+    bl, br := before, before
+    if !before.IsLeaf {
+        bl, br = before.Left.Lift(before.Value), before.Right.Lift(before.Value)
+    }
+    al, ar := after, after
+    if !after.IsLeaf {
+        al, ar = after.Left.Lift(after.Value), after.Right.Lift(after.Value)
+    }
+
+    if interval, counter, ok := dotOf(il, bl, al); ok {
+        return &Id{IsLeaf: false, Left: interval, Right: NewId(0)}, counter, true
+    }
+    if interval, counter, ok := dotOf(ir, br, ar); ok {
+        return &Id{IsLeaf: false, Left: NewId(0), Right: interval}, counter, true
+    }
+
+    return nil, 0, false
+}
@@ -100,7 +100,12 @@ func (event1 *Event) Leq(event2 *Event) bool {
 
     // Case 3: Leq((n1,l1,r2),n2) -> n1<=n2 AND Leq(l1.Lift(n1),n2) AND Leq(r1.Lift(n1),n2)
     if !event1.IsLeaf && event2.IsLeaf {
-        return event1.Value <= event2.Value && event1.Lift(event1.Value).Leq(event2) && event1.Right.Lift(event1.Value).Leq(event2)
+        return event1.Value <= event2.Value && event1.Left.Lift(event1.Value).Leq(event2) && event1.Right.Lift(event1.Value).Leq(event2)
+    }
+
+    // Case 4: Leq((n1,l1,r1),(n2,l2,r2)) -> n1<=n2 AND Leq(l1.Lift(n1),l2.Lift(n2)) AND Leq(r1.Lift(n1),r2.Lift(n2))
+    if !event1.IsLeaf && !event2.IsLeaf {
+        return event1.Value <= event2.Value && event1.Left.Lift(event1.Value).Leq(event2.Left.Lift(event2.Value)) && event1.Right.Lift(event1.Value).Leq(event2.Right.Lift(event2.Value))
     }
 
     return false
@@ -147,11 +152,14 @@ func (event1 *Event) Join(event2 *Event) *Event {
             IsLeaf:true,
             Value:0,
         }
-        event2.IsLeaf = false
-        event2.Left = &a
-        event2.Right = &b
+        top := &Event{
+            IsLeaf: false,
+            Value: event2.Value,
+            Left: &a,
+            Right: &b,
+        }
 
-        return event1.Join(event2)
+        return event1.Join(top)
     }
 
     // Case 4: join((n1,l1,r1),(n2,l2,r2)) -> join((n2,l2,r2),(n1,l1,r1)) if n1 > n2
@@ -176,6 +184,71 @@ func (event1 *Event) Join(event2 *Event) *Event {
     return nil
 }
 
+// Greatest lower bound of the event trees - the pointwise minimum, in normal form
+func (event1 *Event) Meet(event2 *Event) *Event {
+    // Case 1: meet(n1,n2) -> min(n1,n2)
+    if event1.IsLeaf && event2.IsLeaf {
+        if event1.Value < event2.Value {
+            return NewEvent(event1.Value)
+        }
+        return NewEvent(event2.Value)
+    }
+
+    // Case 2: meet(n1,(n2,l2,r2)) -> meet((n1,0,0),(n2,l2,r2))
+    if event1.IsLeaf {
+        top := &Event{
+            IsLeaf: false,
+            Value: event1.Value,
+            Left: NewEvent(0),
+            Right: NewEvent(0),
+        }
+
+        return top.Meet(event2)
+    }
+
+    // Case 3: meet((n1,l1,r1),n2) -> meet((n1,l1,r1),(n2,0,0))
+    if event2.IsLeaf {
+        top := &Event{
+            IsLeaf: false,
+            Value: event2.Value,
+            Left: NewEvent(0),
+            Right: NewEvent(0),
+        }
+
+        return event1.Meet(top)
+    }
+
+    // Case 4: meet((n1,l1,r1),(n2,l2,r2)) -> norm((m,meet(l1.Lift(n1-m),l2.Lift(n2-m)),meet(r1.Lift(n1-m),r2.Lift(n2-m))))
+    // where m = min(n1,n2)
+    m := event1.Value
+    if event2.Value < m {
+        m = event2.Value
+    }
+
+    event := &Event{
+        IsLeaf: false,
+        Value: m,
+        Left: event1.Left.Lift(event1.Value - m).Meet(event2.Left.Lift(event2.Value - m)),
+        Right: event1.Right.Lift(event1.Value - m).Meet(event2.Right.Lift(event2.Value - m)),
+    }
+
+    return event.Norm()
+}
+
+// Meet of any number of events, nil if there are none
+func MeetAll(events ...*Event) *Event {
+    if len(events) == 0 {
+        return nil
+    }
+
+    e := events[0].Norm()
+    for _, event := range events[1:] {
+        e = e.Meet(event)
+    }
+
+    return e
+}
+
 // Shallow copy an event
 func (event *Event) Copy() *Event {
     e := Event{
@@ -0,0 +1,460 @@
+package itc
+
+// Flat trees keep a whole event or id tree in one slice, in preorder: a node is followed by its left subtree and then
+// its right subtree, so a tree is a single allocation walked front to back instead of a web of pointers. Event nodes
+// also record where their right branch starts so comparisons can jump over the subtrees they do not need. Trees are
+// converted to and from the protobuf trees with Flatten and Tree.
+
+// A node of a flat event tree. Right is the distance from a node to its right branch, its left branch following it
+// directly, and 0 on a leaf.
+type EventNode struct {
+    Value uint32
+    Right uint32
+}
+
+func (node EventNode) IsLeaf() bool {
+    return node.Right == 0
+}
+
+// An event tree in preorder
+type FlatEvent []EventNode
+
+// The kinds of node of a flat id tree
+const (
+    FlatIdZero byte = iota
+    FlatIdOne
+    FlatIdNode
+)
+
+// An id tree in preorder
+type FlatId []byte
+
+// A stamp with flat trees
+type FlatStamp struct {
+    Id    FlatId
+    Event FlatEvent
+}
+
+func FlattenEvent(event *Event) FlatEvent {
+    return appendEvent(nil, event)
+}
+
+func appendEvent(flat FlatEvent, event *Event) FlatEvent {
+    k := len(flat)
+    flat = append(flat, EventNode{Value: event.Value})
+    if !event.IsLeaf {
+        flat = appendEvent(flat, event.Left)
+        flat[k].Right = uint32(len(flat) - k)
+        flat = appendEvent(flat, event.Right)
+    }
+    return flat
+}
+
+// The protobuf tree
+func (flat FlatEvent) Tree() *Event {
+    return flat.tree(0)
+}
+
+func (flat FlatEvent) tree(i int) *Event {
+    if flat[i].IsLeaf() {
+        return NewEvent(flat[i].Value)
+    }
+    return &Event{IsLeaf: false, Value: flat[i].Value, Left: flat.tree(i + 1), Right: flat.tree(i + int(flat[i].Right))}
+}
+
+func FlattenId(id *Id) FlatId {
+    return appendId(nil, id)
+}
+
+func appendId(flat FlatId, id *Id) FlatId {
+    if id.IsLeaf {
+        if id.Value == 0 {
+            return append(flat, FlatIdZero)
+        }
+        return append(flat, FlatIdOne)
+    }
+    flat = append(flat, FlatIdNode)
+    flat = appendId(flat, id.Left)
+    return appendId(flat, id.Right)
+}
+
+// The protobuf tree
+func (flat FlatId) Tree() *Id {
+    id, _ := flat.tree(0)
+    return id
+}
+
+func (flat FlatId) tree(i int) (*Id, int) {
+    switch flat[i] {
+    case FlatIdZero:
+        return NewId(0), i + 1
+    case FlatIdOne:
+        return NewId(1), i + 1
+    }
+    left, j := flat.tree(i + 1)
+    right, k := flat.tree(j)
+    return &Id{IsLeaf: false, Left: left, Right: right}, k
+}
+
+func Flatten(stamp *Stamp) FlatStamp {
+    return FlatStamp{Id: FlattenId(stamp.Id), Event: FlattenEvent(stamp.Event)}
+}
+
+// The protobuf stamp
+func (stamp FlatStamp) Stamp() *Stamp {
+    return &Stamp{Id: stamp.Id.Tree(), Event: stamp.Event.Tree()}
+}
+
+// The position just past the subtree starting at i, found down its rightmost path
+func (flat FlatEvent) skip(i int) int {
+    for !flat[i].IsLeaf() {
+        i += int(flat[i].Right)
+    }
+    return i + 1
+}
+
+func (flat FlatId) skip(i int) int {
+    for open := 1; open > 0; i++ {
+        if flat[i] == FlatIdNode {
+            open++
+        } else {
+            open--
+        }
+    }
+    return i
+}
+
+// Same as Event.Leq
+func (event1 FlatEvent) Leq(event2 FlatEvent) bool {
+    return event1.leq(0, 0, event2, 0, 0)
+}
+
+// Compare the subtrees at i and j lifted by o1 and o2
+func (event1 FlatEvent) leq(i int, o1 uint32, event2 FlatEvent, j int, o2 uint32) bool {
+    n1 := event1[i].Value + o1
+    n2 := event2[j].Value + o2
+
+    // Cases 1 and 2: leq(n1,n2) and leq(n1,(n2,l2,r2)) -> n1 <= n2
+    if n1 > n2 {
+        return false
+    }
+    if event1[i].IsLeaf() {
+        return true
+    }
+
+    // Case 3: leq((n1,l1,r1),n2) -> leq(l1.Lift(n1),n2) and leq(r1.Lift(n1),n2)
+    r1 := i + int(event1[i].Right)
+    if event2[j].IsLeaf() {
+        return event1.leq(i+1, n1, event2, j, o2) && event1.leq(r1, n1, event2, j, o2)
+    }
+
+    // Case 4: leq((n1,l1,r1),(n2,l2,r2)) -> leq(l1.Lift(n1),l2.Lift(n2)) and leq(r1.Lift(n1),r2.Lift(n2))
+    r2 := j + int(event2[j].Right)
+    return event1.leq(i+1, n1, event2, j+1, n2) && event1.leq(r1, n1, event2, r2, n2)
+}
+
+// A lone zero leaf, the branches of a leaf joined as a node
+var flatZero = FlatEvent{{Value: 0}}
+
+// A subtree of a flat event, lifted by an offset
+type flatCursor struct {
+    event  FlatEvent
+    i      int
+    offset uint32
+}
+
+func (c flatCursor) node() EventNode {
+    return c.event[c.i]
+}
+
+// The left branch lifted by offset, a zero leaf if the cursor is on a leaf
+func (c flatCursor) left(offset uint32) flatCursor {
+    if c.node().IsLeaf() {
+        return flatCursor{flatZero, 0, offset}
+    }
+    return flatCursor{c.event, c.i + 1, offset}
+}
+
+// The right branch lifted by offset, a zero leaf if the cursor is on a leaf
+func (c flatCursor) right(offset uint32) flatCursor {
+    if c.node().IsLeaf() {
+        return flatCursor{flatZero, 0, offset}
+    }
+    return flatCursor{c.event, c.i + int(c.node().Right), offset}
+}
+
+// Same as Event.Join
+func (event1 FlatEvent) Join(event2 FlatEvent) FlatEvent {
+    out := make(FlatEvent, 0, len(event1)+len(event2))
+    return joinFlat(out, flatCursor{event1, 0, 0}, flatCursor{event2, 0, 0})
+}
+
+// Append the join of the two subtrees
+func joinFlat(out FlatEvent, c1 flatCursor, c2 flatCursor) FlatEvent {
+    n1 := c1.node().Value + c1.offset
+    n2 := c2.node().Value + c2.offset
+
+    // Case 1: join(n1,n2) -> max(n1,n2)
+    if c1.node().IsLeaf() && c2.node().IsLeaf() {
+        if n2 > n1 {
+            n1 = n2
+        }
+        return append(out, EventNode{Value: n1})
+    }
+
+    // Case 4: join((n1,l1,r1),(n2,l2,r2)) -> join((n2,l2,r2),(n1,l1,r1)) if n1 > n2
+    if n1 > n2 {
+        c1, c2 = c2, c1
+        n1, n2 = n2, n1
+    }
+
+    // Cases 2, 3 and 5: norm((n1,join(l1,l2.Lift(n2-n1)),join(r1,r2.Lift(n2-n1)))), a leaf n joining as (n,0,0)
+    k := len(out)
+    out = append(out, EventNode{Value: n1})
+    out = joinFlat(out, c1.left(0), c2.left(n2-n1))
+    out[k].Right = uint32(len(out) - k)
+    out = joinFlat(out, c1.right(0), c2.right(n2-n1))
+
+    return normFlat(out, k)
+}
+
+// Norm the node at k, whose normalised branches end the slice
+func normFlat(out FlatEvent, k int) FlatEvent {
+    r := k + int(out[k].Right)
+    l, rr := out[k+1], out[r]
+
+    // Case 2: Norm((n,m,m)) -> (n+m)
+    if l.IsLeaf() && rr.IsLeaf() && l.Value == rr.Value {
+        out[k] = EventNode{Value: out[k].Value + l.Value}
+        return out[:k+1]
+    }
+
+    // Case 3: Norm((n,e1,e2)) -> (n+m,e1.Sink(m),e2.Sink(m))
+    m := l.Value
+    if rr.Value < m {
+        m = rr.Value
+    }
+    out[k].Value += m
+    out[k+1].Value -= m
+    out[r].Value -= m
+
+    return out
+}
+
+// The largest value in the subtree at i
+func (flat FlatEvent) max(i int) uint32 {
+    if flat[i].IsLeaf() {
+        return flat[i].Value
+    }
+    l, r := flat.max(i+1), flat.max(i+int(flat[i].Right))
+    if r > l {
+        l = r
+    }
+    return flat[i].Value + l
+}
+
+// Same as Stamp.Fill
+func (stamp FlatStamp) Fill() FlatEvent {
+    out := make(FlatEvent, 0, len(stamp.Event))
+    out, _ = fillFlat(out, stamp.Id, 0, stamp.Event, 0)
+    return out
+}
+
+// Append the fill of the event subtree at i under the id subtree at p, returning where the id subtree ended
+func fillFlat(out FlatEvent, id FlatId, p int, event FlatEvent, i int) (FlatEvent, int) {
+    switch {
+    // Case 1: fill(0,e) -> e
+    case id[p] == FlatIdZero:
+        return append(out, event[i:event.skip(i)]...), p + 1
+
+    // Case 2: fill(1,e) -> max(e)
+    case id[p] == FlatIdOne:
+        return append(out, EventNode{Value: event.max(i)}), p + 1
+
+    // Case 3: fill(i,n) -> n
+    case event[i].IsLeaf():
+        return append(out, event[i]), id.skip(p)
+    }
+
+    k := len(out)
+    out = append(out, EventNode{Value: event[i].Value})
+    il, el, er := p+1, i+1, i+int(event[i].Right)
+
+    // Case 4: fill((1,ir),(n,el,er)) -> norm((n,max(max(el),min(erprime)),erprime))
+    if id[il] == FlatIdOne {
+        out = append(out, EventNode{})
+        out[k].Right = 2
+        var end int
+        out, end = fillFlat(out, id, il+1, event, er)
+        out[k+1].Value = event.max(el)
+        if v := out[k+2].Value; v > out[k+1].Value {
+            out[k+1].Value = v
+        }
+        return normFlat(out, k), end
+    }
+
+    out, ir := fillFlat(out, id, il, event, el)
+    out[k].Right = uint32(len(out) - k)
+
+    // Case 5: fill((il,1),(n,el,er)) -> norm((n,elprime,max(max(er),min(elprime))))
+    if id[ir] == FlatIdOne {
+        maxr := event.max(er)
+        if v := out[k+1].Value; v > maxr {
+            maxr = v
+        }
+        out = append(out, EventNode{Value: maxr})
+        return normFlat(out, k), ir + 1
+    }
+
+    // Case 6: fill((il,ir),(n,el,er)) -> norm((n,fill(il,el),fill(ir,er)))
+    out, end := fillFlat(out, id, ir, event, er)
+    return normFlat(out, k), end
+}
+
+// Same as Stamp.Grow. Nil if the event cannot grow under the id, which Fill rules out.
+func (stamp FlatStamp) Grow() (FlatEvent, uint32) {
+    return stamp.GrowWith(DefaultGrowPolicy)
+}
+
+// Same as Stamp.GrowWith. One pass prices every place the event could grow and records the choices made on the way
+// up, a second builds the event along them.
+func (stamp FlatStamp) GrowWith(policy GrowPolicy) (FlatEvent, uint32) {
+    cost, choices, ok := growCost(policy, stamp.Id, 0, stamp.Event, 0, nil)
+    if !ok {
+        return nil, 0
+    }
+
+    out := make(FlatEvent, 0, len(stamp.Event)+2)
+    out, _ = growFlat(out, stamp.Id, 0, stamp.Event, 0, choices, 0)
+    return out, cost
+}
+
+// The event (0,0,0) a leaf grows through, its root value set when copied. Only ever read.
+var expansionFlat = FlatEvent{{Right: 2}, {}, {}}
+
+// The choice at a node where both branches of the id own part of the interval: whether to grow on the left, and the
+// index of the first choice in the right branch. Choices are recorded in preorder.
+type growChoice struct {
+    left  bool
+    right int
+}
+
+// The cost of growing the event subtree at i under the id subtree at p, appending the choices made below it, false if
+// it cannot grow
+func growCost(policy GrowPolicy, id FlatId, p int, event FlatEvent, i int, choices []growChoice) (uint32, []growChoice,
+    bool) {
+
+    switch {
+    // Case 1: grow(1,n) -> (n+1,0)
+    case id[p] == FlatIdOne && event[i].IsLeaf():
+        return 0, choices, true
+    case id[p] != FlatIdNode:
+        return 0, choices, false
+    // Case 2: grow(i,n) -> (eprime,expand(c))
+    case event[i].IsLeaf():
+        c, choices, ok := growCost(policy, id, p, expansionFlat, 0, choices)
+        return policy.Expand(c), choices, ok
+    }
+
+    il, ir := p+1, id.skip(p+1)
+    el, er := i+1, i+int(event[i].Right)
+
+    switch {
+    // Case 3: grow((0,ir),(n,el,er)) -> ((n,el,erprime),descend(cr))
+    case id[il] == FlatIdZero:
+        c, choices, ok := growCost(policy, id, ir, event, er, choices)
+        return policy.Descend(c), choices, ok
+    // Case 4: grow((il,0),(n,el,er)) -> ((n,elprime,er),descend(cl))
+    case id[ir] == FlatIdZero:
+        c, choices, ok := growCost(policy, id, il, event, el, choices)
+        return policy.Descend(c), choices, ok
+    }
+
+    // Case 5: the cheaper branch, the left if cl < cr, or as a WeighingGrowPolicy chooses
+    k := len(choices)
+    choices = append(choices, growChoice{})
+    cl, choices, okl := growCost(policy, id, il, event, el, choices)
+    choices[k].right = len(choices)
+    cr, choices, okr := growCost(policy, id, ir, event, er, choices)
+    if growLeftFlat(policy, cl, cr, id, il, ir) {
+        choices[k].left = true
+        return policy.Descend(cl), choices, okl
+    }
+    return policy.Descend(cr), choices, okr
+}
+
+// Append the event subtree at i grown under the id subtree at p along the choices from k on, copying every branch it
+// does not grow in
+func growFlat(out FlatEvent, id FlatId, p int, event FlatEvent, i int, choices []growChoice, k int) (FlatEvent, bool) {
+    switch {
+    case id[p] == FlatIdOne && event[i].IsLeaf():
+        return append(out, EventNode{Value: event[i].Value + 1}), true
+    case id[p] != FlatIdNode:
+        return out, false
+    case event[i].IsLeaf():
+        root := len(out)
+        out, ok := growFlat(out, id, p, expansionFlat, 0, choices, k)
+        out[root].Value = event[i].Value
+        return out, ok
+    }
+
+    il, ir := p+1, id.skip(p+1)
+    el, er := i+1, i+int(event[i].Right)
+
+    left := id[ir] == FlatIdZero
+    if id[il] != FlatIdZero && id[ir] != FlatIdZero {
+        left = choices[k].left
+        if left {
+            k++
+        } else {
+            k = choices[k].right
+        }
+    }
+
+    n := len(out)
+    out = append(out, EventNode{Value: event[i].Value})
+    ok := true
+    if left {
+        out, ok = growFlat(out, id, il, event, el, choices, k)
+        out[n].Right = uint32(len(out) - n)
+        out = append(out, event[er:event.skip(er)]...)
+    } else {
+        out = append(out, event[el:er]...)
+        out[n].Right = uint32(len(out) - n)
+        out, ok = growFlat(out, id, ir, event, er, choices, k)
+    }
+    return out, ok
+}
+
+// Same as Stamp.Advance
+func (stamp FlatStamp) Advance() FlatStamp {
+    return stamp.AdvanceWith(DefaultGrowPolicy)
+}
+
+// Same as Stamp.AdvanceWith
+func (stamp FlatStamp) AdvanceWith(policy GrowPolicy) FlatStamp {
+    e := stamp.Fill()
+    if !e.Equal(stamp.Event) {
+        return FlatStamp{Id: stamp.Id, Event: e}
+    }
+    e, _ = stamp.GrowWith(policy)
+    return FlatStamp{Id: stamp.Id, Event: e}
+}
+
+// Same as Event.Leq on the stamps' events
+func (s1 FlatStamp) Leq(s2 FlatStamp) bool {
+    return s1.Event.Leq(s2.Event)
+}
+
+// True if the trees are identical
+func (event1 FlatEvent) Equal(event2 FlatEvent) bool {
+    if len(event1) != len(event2) {
+        return false
+    }
+    for i := range event1 {
+        if event1[i] != event2[i] {
+            return false
+        }
+    }
+    return true
+}
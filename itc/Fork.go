@@ -0,0 +1,97 @@
+package itc
+
+import (
+    "math/big"
+)
+
+// Fork the stamp into n stamps with disjoint ids of equal size, nil if n < 1
+func (stamp *Stamp) ForkN(n int) []*Stamp {
+    if n < 1 {
+        return nil
+    }
+
+    weights := make([]uint, n)
+    for i := range weights {
+        weights[i] = 1
+    }
+
+    return stamp.ForkWeighted(weights...)
+}
+
+// Fork the stamp into one stamp per weight, each owning a share of the id proportional to its weight: weights 3 and 1
+// give one stamp three quarters of the id and the other a quarter. Nil if there are no weights or any is 0.
+//
+// Unlike chained calls to Fork, the id is cut in one step into contiguous runs of its region, none more than
+// log2(sum of weights)+1 levels deeper than the id, with shares rounded to that resolution.
+func (stamp *Stamp) ForkWeighted(weights ...uint) []*Stamp {
+    if len(weights) == 0 {
+        return nil
+    }
+    total := new(big.Int)
+    for _, w := range weights {
+        if w == 0 {
+            return nil
+        }
+        total.Add(total, new(big.Int).SetUint64(uint64(w)))
+    }
+
+    ids := splitWeighted(stamp.Id, weights, total)
+
+    stamps := make([]*Stamp, len(ids))
+    for i, id := range ids {
+        stamps[i] = NewStamp(id, stamp.Event)
+    }
+
+    return stamps
+}
+
+// Cut the id into runs with sizes proportional to the weights
+func splitWeighted(id *Id, weights []uint, total *big.Int) []*Id {
+    ids := make([]*Id, len(weights))
+    if id.IsEmpty() {
+        for i := range ids {
+            ids[i] = NewId(0)
+        }
+        return ids
+    }
+
+    // Deep enough that every weight gets at least one unit
+    depth := id.Depth() + total.BitLen()
+    size := measure(id, depth)
+    shares := apportion(size, weights, total)
+
+    rest := id
+    unit := new(big.Int).Lsh(big.NewInt(1), uint(depth))
+    for i, share := range shares[:len(shares)-1] {
+        ids[i], rest, _ = rest.takeLeft(share, unit)
+    }
+    ids[len(ids)-1] = rest
+
+    return ids
+}
+
+// Divide size units between the weights by largest remainder, earlier weights winning ties
+func apportion(size *big.Int, weights []uint, total *big.Int) []*big.Int {
+    shares := make([]*big.Int, len(weights))
+    remainders := make([]*big.Int, len(weights))
+    left := new(big.Int).Set(size)
+
+    for i, w := range weights {
+        product := new(big.Int).Mul(size, new(big.Int).SetUint64(uint64(w)))
+        shares[i], remainders[i] = new(big.Int).QuoRem(product, total, new(big.Int))
+        left.Sub(left, shares[i])
+    }
+
+    for ; left.Sign() > 0; left.Sub(left, big.NewInt(1)) {
+        best := -1
+        for i, r := range remainders {
+            if r.Sign() > 0 && (best < 0 || r.Cmp(remainders[best]) > 0) {
+                best = i
+            }
+        }
+        shares[best].Add(shares[best], big.NewInt(1))
+        remainders[best].SetInt64(0)
+    }
+
+    return shares
+}
@@ -0,0 +1,94 @@
+package itc
+
+// A GrowPolicy prices the places Grow could add an event, when Fill cannot record it by simplifying the tree. Grow
+// works out the cost of growing at every leaf of the id and takes the cheapest, preferring the right on ties, unless
+// the policy is a WeighingGrowPolicy.
+type GrowPolicy interface {
+    // The cost of growing below an event leaf that first has to be expanded into a node, c being the cost below it
+    Expand(c uint32) uint32
+    // The cost of growing one level further down the event tree, c being the cost below it
+    Descend(c uint32) uint32
+}
+
+// A GrowPolicy adding a fixed cost per expanded leaf and per level
+type CostPolicy struct {
+    ExpandCost  uint32
+    DescendCost uint32
+}
+
+func (p CostPolicy) Expand(c uint32) uint32 {
+    return c + p.ExpandCost
+}
+
+func (p CostPolicy) Descend(c uint32) uint32 {
+    return c + p.DescendCost
+}
+
+// A GrowPolicy that also weighs how much of the interval the two branches of the id own where Grow has to choose
+// between them
+type WeighingGrowPolicy interface {
+    GrowPolicy
+    // Whether to grow in the left branch of a node of the id where both branches own part of the interval. cl and cr
+    // are the costs of growing in each branch, ml and mr the fractions of its half of the interval each branch owns.
+    Left(cl, cr uint32, ml, mr float64) bool
+}
+
+// A WeighingGrowPolicy growing in the branch of the id that owns more of the interval, whatever the depth of the
+// event, and by cost only where both own as much
+type MassPolicy struct {
+    CostPolicy
+}
+
+func (p MassPolicy) Left(cl, cr uint32, ml, mr float64) bool {
+    if ml != mr {
+        return ml > mr
+    }
+    return cl < cr
+}
+
+var (
+    // The paper's policy: avoid expanding leaves, adding nodes to the event tree, then prefer shorter paths
+    DefaultGrowPolicy GrowPolicy = CostPolicy{ExpandCost: GrowIncrement, DescendCost: 1}
+    // Grow where the new event lands least deep in the tree: every level costs 1, expanded or not. The event lands
+    // under the shallowest 1 in the id, which is also the largest single piece of the interval the id owns.
+    ShallowGrowPolicy GrowPolicy = CostPolicy{ExpandCost: 0, DescendCost: 1}
+    // Grow into the largest subtree of the id, following the branch that owns more of the interval at every choice
+    // rather than the shallowest 1, so the new event lands where later fills can collapse the most of the tree
+    LargestSubtreeGrowPolicy GrowPolicy = MassPolicy{CostPolicy{ExpandCost: GrowIncrement, DescendCost: 1}}
+)
+
+// Whether Grow takes the left branch of a node of the id where both branches own part of the interval, cl and cr
+// being the costs of growing in each
+func growLeft(policy GrowPolicy, cl, cr uint32, il, ir *Id) bool {
+    if p, ok := policy.(WeighingGrowPolicy); ok {
+        return p.Left(cl, cr, il.mass(), ir.mass())
+    }
+    return cl < cr
+}
+
+// Same as growLeft for the branches at il and ir of a flat id
+func growLeftFlat(policy GrowPolicy, cl, cr uint32, id FlatId, il, ir int) bool {
+    if p, ok := policy.(WeighingGrowPolicy); ok {
+        return p.Left(cl, cr, id.mass(il), id.mass(ir))
+    }
+    return cl < cr
+}
+
+// The fraction of its part of the interval the id owns
+func (id *Id) mass() float64 {
+    if id.IsLeaf {
+        return float64(id.Value)
+    }
+    return (id.Left.mass() + id.Right.mass()) / 2
+}
+
+// The fraction of its part of the interval the id subtree at p owns
+func (flat FlatId) mass(p int) float64 {
+    switch flat[p] {
+    case FlatIdZero:
+        return 0
+    case FlatIdOne:
+        return 1
+    }
+    return (flat.mass(p+1) + flat.mass(flat.skip(p+1))) / 2
+}
@@ -60,23 +60,25 @@ func (id *Id) Split() (*Id,*Id) {
     if !id.IsLeaf && id.Right.IsLeaf && id.Right.Value ==0 {
         ida,idb := id.Left.Split()
 
+        id1.IsLeaf = false
         id1.Left = ida
-        id1.Right.IsLeaf = true
+        id1.Right = NewId(0)
 
+        id2.IsLeaf = false
         id2.Left = idb
-        id2.Right.IsLeaf = true
+        id2.Right = NewId(0)
 
         return id1,id2
     }
 
     // Case 5: split((i1,i2)) -> ((i1,0),(0,i2))
     if !id.IsLeaf {
+        id1.IsLeaf = false
         id1.Left = id.Left
-        id1.Right.Value = 0
-        id1.Right.IsLeaf = true
+        id1.Right = NewId(0)
 
-        id2.Left.Value = 0
-        id2.Left.IsLeaf = true
+        id2.IsLeaf = false
+        id2.Left = NewId(0)
         id2.Right = id.Right
 
         return id1,id2
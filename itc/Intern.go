@@ -0,0 +1,177 @@
+package itc
+
+import "sync"
+
+// An Interner stores each distinct event and id subtree once, so that stamps sharing history share memory and
+// identical subtrees are the same pointer. Subtrees are keyed by their value and their already interned branches, so
+// interning a tree looks up each node once, bottom up.
+//
+// Interned trees are shared between every stamp that contains them and must never be modified. Nothing in this
+// package changes the trees it is given, it only builds new ones. The table keeps every subtree it has seen alive
+// for as long as the Interner is reachable.
+type Interner struct {
+    mu     sync.Mutex
+    events map[eventKey]*Event
+    ids    map[idKey]*Id
+}
+
+type eventKey struct {
+    leaf        bool
+    value       uint32
+    left, right *Event
+}
+
+type idKey struct {
+    leaf        bool
+    value       uint32
+    left, right *Id
+}
+
+func NewInterner() *Interner {
+    return &Interner{events: map[eventKey]*Event{}, ids: map[idKey]*Id{}}
+}
+
+// The interned copy of the event
+func (in *Interner) Event(event *Event) *Event {
+    in.mu.Lock()
+    defer in.mu.Unlock()
+    return in.event(event)
+}
+
+// The interned copy of the id
+func (in *Interner) Id(id *Id) *Id {
+    in.mu.Lock()
+    defer in.mu.Unlock()
+    return in.id(id)
+}
+
+// A stamp of the interned copies of the stamp's trees. NewStamp would copy the roots.
+func (in *Interner) Stamp(stamp *Stamp) *Stamp {
+    in.mu.Lock()
+    defer in.mu.Unlock()
+    return &Stamp{Id: in.id(stamp.Id), Event: in.event(stamp.Event)}
+}
+
+// The number of distinct event and id nodes held
+func (in *Interner) Len() (events int, ids int) {
+    in.mu.Lock()
+    defer in.mu.Unlock()
+    return len(in.events), len(in.ids)
+}
+
+func (in *Interner) event(event *Event) *Event {
+    // Already interned: the node is stored under its own branches
+    if e, ok := in.events[eventKey{event.IsLeaf, event.Value, event.Left, event.Right}]; ok && e == event {
+        return e
+    }
+    if event.IsLeaf {
+        return in.eventNode(true, event.Value, nil, nil)
+    }
+    return in.eventNode(false, event.Value, in.event(event.Left), in.event(event.Right))
+}
+
+// The interned node over interned branches
+func (in *Interner) eventNode(leaf bool, value uint32, left *Event, right *Event) *Event {
+    key := eventKey{leaf, value, left, right}
+    if e, ok := in.events[key]; ok {
+        return e
+    }
+    e := &Event{IsLeaf: leaf, Value: value, Left: left, Right: right}
+    in.events[key] = e
+    return e
+}
+
+func (in *Interner) id(id *Id) *Id {
+    if i, ok := in.ids[idKey{id.IsLeaf, id.Value, id.Left, id.Right}]; ok && i == id {
+        return i
+    }
+    if id.IsLeaf {
+        return in.idNode(true, id.Value, nil, nil)
+    }
+    return in.idNode(false, 0, in.id(id.Left), in.id(id.Right))
+}
+
+func (in *Interner) idNode(leaf bool, value uint32, left *Id, right *Id) *Id {
+    key := idKey{leaf, value, left, right}
+    if i, ok := in.ids[key]; ok {
+        return i
+    }
+    i := &Id{IsLeaf: leaf, Value: value, Left: left, Right: right}
+    in.ids[key] = i
+    return i
+}
+
+// Same as Event.Leq, answering at once for identical subtrees
+func (in *Interner) Leq(event1 *Event, event2 *Event) bool {
+    return leqAt(event1, 0, event2, 0)
+}
+
+// Same as Event.Join on normalised events, giving an interned event and reusing identical subtrees as they are
+func (in *Interner) Join(event1 *Event, event2 *Event) *Event {
+    in.mu.Lock()
+    defer in.mu.Unlock()
+    return in.join(in.event(event1), 0, in.event(event2), 0)
+}
+
+// The join of interned events lifted by o1 and o2
+func (in *Interner) join(event1 *Event, o1 uint32, event2 *Event, o2 uint32) *Event {
+    // The same subtree joined with itself is the higher of the two
+    if event1 == event2 {
+        if o2 > o1 {
+            o1 = o2
+        }
+        return in.lift(event1, o1)
+    }
+
+    n1 := event1.Value + o1
+    n2 := event2.Value + o2
+
+    // Case 1: join(n1,n2) -> max(n1,n2)
+    if event1.IsLeaf && event2.IsLeaf {
+        if n2 > n1 {
+            n1 = n2
+        }
+        return in.eventNode(true, n1, nil, nil)
+    }
+
+    // Case 4: join((n1,l1,r1),(n2,l2,r2)) -> join((n2,l2,r2),(n1,l1,r1)) if n1 > n2
+    if n1 > n2 {
+        event1, event2 = event2, event1
+        n1, n2 = n2, n1
+    }
+
+    // Cases 2, 3 and 5: norm((n1,join(l1,l2.Lift(n2-n1)),join(r1,r2.Lift(n2-n1)))), a leaf n joining as (n,0,0)
+    l1, r1 := in.branches(event1)
+    l2, r2 := in.branches(event2)
+    l := in.join(l1, 0, l2, n2-n1)
+    r := in.join(r1, 0, r2, n2-n1)
+
+    // Case 2: Norm((n,m,m)) -> (n+m)
+    if l.IsLeaf && r.IsLeaf && l.Value == r.Value {
+        return in.eventNode(true, n1+l.Value, nil, nil)
+    }
+
+    // Case 3: Norm((n,e1,e2)) -> (n+m,e1.Sink(m),e2.Sink(m)), the normalised branches' values being their minimums
+    m := l.Value
+    if r.Value < m {
+        m = r.Value
+    }
+    return in.eventNode(false, n1+m, in.lift(l, -m), in.lift(r, -m))
+}
+
+// The interned event with its root value moved by m, which wraps to sink it
+func (in *Interner) lift(event *Event, m uint32) *Event {
+    if m == 0 {
+        return event
+    }
+    return in.eventNode(event.IsLeaf, event.Value+m, event.Left, event.Right)
+}
+
+// The interned branches, zero leaves for a leaf
+func (in *Interner) branches(event *Event) (*Event, *Event) {
+    if event.IsLeaf {
+        zero := in.eventNode(true, 0, nil, nil)
+        return zero, zero
+    }
+    return event.Left, event.Right
+}
@@ -0,0 +1,494 @@
+package itc
+
+// Iterative versions of the core algorithms. They walk the trees with an explicit stack kept in a slice instead of
+// recursing, so a tree thousands of levels deep costs heap rather than goroutine stack, and they carry lift offsets
+// down instead of copying nodes to lift them. On normalised trees they give the same results as the recursive
+// versions: where those call Min on a subtree just built, which is normalised, these read its root.
+
+// Stacks start in an array of this many frames, so walking a shallow tree allocates nothing for the stack
+const stackFrames = 32
+
+// A subtree lifted by an offset
+type liftedNode struct {
+    event  *Event
+    offset uint32
+}
+
+// Same as Leq
+func (event1 *Event) LeqIterative(event2 *Event) bool {
+    type frame struct {
+        e1, e2 liftedNode
+    }
+
+    var buf [stackFrames]frame
+    stack := append(buf[:0], frame{liftedNode{event1, 0}, liftedNode{event2, 0}})
+
+    for len(stack) > 0 {
+        f := stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+
+        n1 := f.e1.event.Value + f.e1.offset
+        n2 := f.e2.event.Value + f.e2.offset
+
+        // Cases 1 and 2: leq(n1,n2) and leq(n1,(n2,l2,r2)) -> n1 <= n2
+        if n1 > n2 {
+            return false
+        }
+        if f.e1.event.IsLeaf {
+            continue
+        }
+
+        // Case 3: leq((n1,l1,r1),n2) -> leq(l1.Lift(n1),n2) and leq(r1.Lift(n1),n2)
+        l2, r2 := f.e2, f.e2
+        // Case 4: leq((n1,l1,r1),(n2,l2,r2)) -> leq(l1.Lift(n1),l2.Lift(n2)) and leq(r1.Lift(n1),r2.Lift(n2))
+        if !f.e2.event.IsLeaf {
+            l2 = liftedNode{f.e2.event.Left, n2}
+            r2 = liftedNode{f.e2.event.Right, n2}
+        }
+
+        stack = append(stack,
+            frame{liftedNode{f.e1.event.Right, n1}, r2},
+            frame{liftedNode{f.e1.event.Left, n1}, l2})
+    }
+
+    return true
+}
+
+// Same as Min
+func (event *Event) MinIterative() *Event {
+    return NewEvent(event.extremum(func(a, b uint32) bool { return a < b }))
+}
+
+// Same as Max
+func (event *Event) MaxIterative() *Event {
+    return NewEvent(event.extremum(func(a, b uint32) bool { return a > b }))
+}
+
+// The value of the leaf preferred by better over all others, leaves being lifted by the values above them
+func (event *Event) extremum(better func(uint32, uint32) bool) uint32 {
+    var buf [stackFrames]liftedNode
+    stack := append(buf[:0], liftedNode{event, 0})
+
+    found := false
+    var best uint32
+    for len(stack) > 0 {
+        f := stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+
+        n := f.event.Value + f.offset
+        if f.event.IsLeaf {
+            if !found || better(n, best) {
+                best, found = n, true
+            }
+            continue
+        }
+        stack = append(stack, liftedNode{f.event.Right, n}, liftedNode{f.event.Left, n})
+    }
+
+    return best
+}
+
+// Same as Norm, which only recurses through Min
+func (event *Event) NormIterative() *Event {
+    // Case 1: Norm(n) -> n
+    if event.IsLeaf {
+        return event.Copy()
+    }
+
+    // Case 2: Norm((n,m,m)) -> (n+m)
+    if event.Left.IsLeaf && event.Right.IsLeaf && event.Left.Value == event.Right.Value {
+        return NewEvent(event.Value + event.Left.Value)
+    }
+
+    // Case 3: Norm((n,e1,e2)) -> (n+m,e1.Sink(m),e2.Sink(m)) where m = Min(Min(e1),Min(e2))
+    m := event.Left.MinIterative().Value
+    if r := event.Right.MinIterative().Value; r < m {
+        m = r
+    }
+
+    return &Event{
+        IsLeaf: false,
+        Value: event.Value + m,
+        Left: event.Left.Sink(m),
+        Right: event.Right.Sink(m),
+    }
+}
+
+// Norm the node (n,l,r) whose branches are normalised, so their minimums are their roots
+func normNode(n uint32, l *Event, r *Event) *Event {
+    if l.IsLeaf && r.IsLeaf && l.Value == r.Value {
+        return NewEvent(n + l.Value)
+    }
+
+    m := l.Value
+    if r.Value < m {
+        m = r.Value
+    }
+    if m != 0 {
+        l, r = l.Sink(m), r.Sink(m)
+    }
+
+    return &Event{
+        IsLeaf: false,
+        Value: n + m,
+        Left: l,
+        Right: r,
+    }
+}
+
+// Same as Join
+func (event1 *Event) JoinIterative(event2 *Event) *Event {
+    // A frame joins two lifted subtrees, or once both branches are joined, norms them under the value n
+    type frame struct {
+        e1, e2  liftedNode
+        combine bool
+        n       uint32
+    }
+
+    var buf [stackFrames]frame
+    var results [stackFrames]*Event
+    stack := append(buf[:0], frame{e1: liftedNode{event1, 0}, e2: liftedNode{event2, 0}})
+    done := results[:0]
+
+    for len(stack) > 0 {
+        f := stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+
+        if f.combine {
+            l, r := done[len(done)-2], done[len(done)-1]
+            done = append(done[:len(done)-2], normNode(f.n, l, r))
+            continue
+        }
+
+        n1 := f.e1.event.Value + f.e1.offset
+        n2 := f.e2.event.Value + f.e2.offset
+
+        // Case 1: join(n1,n2) -> max(n1,n2)
+        if f.e1.event.IsLeaf && f.e2.event.IsLeaf {
+            if n2 > n1 {
+                n1 = n2
+            }
+            done = append(done, NewEvent(n1))
+            continue
+        }
+
+        // Cases 2 and 3: a leaf n joins as (n,0,0)
+        l1, r1 := zeroBranches(f.e1.event)
+        l2, r2 := zeroBranches(f.e2.event)
+
+        // Case 4: join((n1,l1,r1),(n2,l2,r2)) -> join((n2,l2,r2),(n1,l1,r1)) if n1 > n2
+        if n1 > n2 {
+            n1, n2 = n2, n1
+            l1, r1, l2, r2 = l2, r2, l1, r1
+        }
+
+        // Case 5: join((n1,l1,r1),(n2,l2,r2)) -> norm((n1,join(l1,l2.Lift(n2-n1)),join(r1,r2.Lift(n2-n1))))
+        stack = append(stack,
+            frame{combine: true, n: n1},
+            frame{e1: liftedNode{r1, 0}, e2: liftedNode{r2, n2 - n1}},
+            frame{e1: liftedNode{l1, 0}, e2: liftedNode{l2, n2 - n1}})
+    }
+
+    return done[0]
+}
+
+var zeroLeaf = &Event{IsLeaf: true, Value: 0}
+
+// The branches of the event, (0,0) for a leaf. The shared zero leaf is only ever read.
+func zeroBranches(event *Event) (*Event, *Event) {
+    if event.IsLeaf {
+        return zeroLeaf, zeroLeaf
+    }
+    return event.Left, event.Right
+}
+
+// Same as Fill
+func (stamp *Stamp) FillIterative() *Event {
+    const (
+        visit = iota
+        fillRight
+        fillLeft
+        fillBoth
+    )
+    type frame struct {
+        id    *Id
+        event *Event
+        state int
+    }
+
+    var buf [stackFrames]frame
+    var results [stackFrames]*Event
+    stack := append(buf[:0], frame{stamp.Id, stamp.Event, visit})
+    done := results[:0]
+
+    for len(stack) > 0 {
+        f := stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+        id, event := f.id, f.event
+
+        switch f.state {
+        case fillRight:
+            // Case 4: norm((n,max(max(el),min(erprime)),erprime))
+            erprime := done[len(done)-1]
+            left := event.Left.MaxIterative()
+            if erprime.Value > left.Value {
+                left.Value = erprime.Value
+            }
+            done[len(done)-1] = normNode(event.Value, left, erprime)
+            continue
+        case fillLeft:
+            // Case 5: norm((n,elprime,max(max(er),min(elprime))))
+            elprime := done[len(done)-1]
+            right := event.Right.MaxIterative()
+            if elprime.Value > right.Value {
+                right.Value = elprime.Value
+            }
+            done[len(done)-1] = normNode(event.Value, elprime, right)
+            continue
+        case fillBoth:
+            // Case 6: norm((n,fill(il,el),fill(ir,er)))
+            l, r := done[len(done)-2], done[len(done)-1]
+            done = append(done[:len(done)-2], normNode(event.Value, l, r))
+            continue
+        }
+
+        switch {
+        // Case 1: fill(0,e) -> e
+        case id.IsLeaf && id.Value == 0:
+            done = append(done, event)
+        // Case 2: fill(1,e) -> max(e)
+        case id.IsLeaf && id.Value == 1:
+            done = append(done, event.MaxIterative())
+        // Case 3: fill(i,n) -> n
+        case event.IsLeaf:
+            done = append(done, NewEvent(event.Value))
+        // Case 4: fill((1,ir),(n,el,er))
+        case id.Left.IsLeaf && id.Left.Value == 1:
+            stack = append(stack, frame{id, event, fillRight}, frame{id.Right, event.Right, visit})
+        // Case 5: fill((il,1),(n,el,er))
+        case id.Right.IsLeaf && id.Right.Value == 1:
+            stack = append(stack, frame{id, event, fillLeft}, frame{id.Left, event.Left, visit})
+        // Case 6: fill((il,ir),(n,el,er))
+        default:
+            stack = append(stack,
+                frame{id, event, fillBoth},
+                frame{id.Right, event.Right, visit},
+                frame{id.Left, event.Left, visit})
+        }
+    }
+
+    return done[0]
+}
+
+// Same as Grow
+func (stamp *Stamp) GrowIterative() (*Event, uint32) {
+    return stamp.GrowWithIterative(DefaultGrowPolicy)
+}
+
+// Same as GrowWith. A first pass prices growing below every leaf of the id, recording which way the cheaper branch
+// lies wherever both branches of the id own part of the interval. A second follows those choices down and builds just
+// the path to the new event, every node of it taken from one allocation.
+func (stamp *Stamp) GrowWithIterative(policy GrowPolicy) (*Event, uint32) {
+    const (
+        visit = iota
+        expanded
+        descended
+        rightStarts
+        chosen
+    )
+    type frame struct {
+        id    *Id
+        event *Event
+        state int
+        // The choice of a node with both branches
+        k int
+    }
+    // At a node with both branches: whether to grow on the left, and the index of the first choice in the right branch
+    type choice struct {
+        left  bool
+        right int
+    }
+
+    var buf [stackFrames]frame
+    var costBuf [stackFrames]uint32
+    var choiceBuf [stackFrames]choice
+    stack := append(buf[:0], frame{id: stamp.Id, event: stamp.Event, state: visit})
+    costs := costBuf[:0]
+    choices := choiceBuf[:0]
+
+    for len(stack) > 0 {
+        f := stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+
+        switch f.state {
+        case expanded:
+            costs[len(costs)-1] = policy.Expand(costs[len(costs)-1])
+            continue
+        case descended:
+            costs[len(costs)-1] = policy.Descend(costs[len(costs)-1])
+            continue
+        case rightStarts:
+            choices[f.k].right = len(choices)
+            continue
+        case chosen:
+            // Case 5: grow on the left if cl < cr, otherwise on the right, or as a WeighingGrowPolicy chooses
+            cl, cr := costs[len(costs)-2], costs[len(costs)-1]
+            if growLeft(policy, cl, cr, f.id.Left, f.id.Right) {
+                choices[f.k].left = true
+                cr = cl
+            }
+            costs = append(costs[:len(costs)-2], policy.Descend(cr))
+            continue
+        }
+
+        // Case 1: grow(1,n) -> (n+1,0). A filled event is a leaf wherever the id is 1.
+        if f.id.IsLeaf {
+            costs = append(costs, 0)
+            continue
+        }
+
+        // Case 2: grow(i,n) -> (eprime,expand(c)) where (eprime,c) = grow(i,(n,0,0))
+        if f.event.IsLeaf {
+            stack = append(stack, frame{state: expanded})
+        }
+        el, er := zeroBranches(f.event)
+
+        switch id := f.id; {
+        // Case 3: grow((0,ir),(n,el,er))
+        case id.Left.IsLeaf && id.Left.Value == 0:
+            stack = append(stack, frame{state: descended}, frame{id: id.Right, event: er, state: visit})
+        // Case 4: grow((il,0),(n,el,er))
+        case id.Right.IsLeaf && id.Right.Value == 0:
+            stack = append(stack, frame{state: descended}, frame{id: id.Left, event: el, state: visit})
+        // Case 5: grow((il,ir),(n,el,er))
+        default:
+            k := len(choices)
+            choices = append(choices, choice{})
+            stack = append(stack,
+                frame{id: id, state: chosen, k: k},
+                frame{id: id.Right, event: er, state: visit},
+                frame{state: rightStarts, k: k},
+                frame{id: id.Left, event: el, state: visit})
+        }
+    }
+
+    // The way down to the leaf of the id the event grows under, true for left
+    var pathBuf [stackFrames]bool
+    path := pathBuf[:0]
+    id := stamp.Id
+    for k := 0; !id.IsLeaf; {
+        left := false
+        switch {
+        case id.Left.IsLeaf && id.Left.Value == 0:
+        case id.Right.IsLeaf && id.Right.Value == 0:
+            left = true
+        default:
+            left = choices[k].left
+            if left {
+                k++
+            } else {
+                k = choices[k].right
+            }
+        }
+
+        path = append(path, left)
+        if left {
+            id = id.Left
+        } else {
+            id = id.Right
+        }
+    }
+
+    // A node per level, the zero leaf beside it where an event leaf is expanded, and the new leaf
+    nodes := make([]Event, 2*len(path)+1)
+    var root *Event
+    slot, event := &root, stamp.Event
+    for _, left := range path {
+        node := &nodes[0]
+        nodes = nodes[1:]
+        *slot = node
+        node.Value, node.Left, node.Right = event.Value, event.Left, event.Right
+        if event.IsLeaf {
+            zero := &nodes[0]
+            nodes = nodes[1:]
+            zero.IsLeaf = true
+            node.Left, node.Right = zero, zero
+        }
+
+        if left {
+            slot, event = &node.Left, node.Left
+        } else {
+            slot, event = &node.Right, node.Right
+        }
+    }
+    if id.Value == 1 && event.IsLeaf {
+        *slot = &nodes[0]
+        (*slot).IsLeaf, (*slot).Value = true, event.Value+1
+    } else {
+        *slot = nil
+    }
+
+    return root, costs[0]
+}
+
+// Same as Split. Only cases 3 and 4 recurse, each into one branch, so the path down is all the stack needed. Every
+// node of both results comes from one allocation.
+func (id *Id) SplitIterative() (*Id, *Id) {
+    var buf [stackFrames]bool
+    right := buf[:0]
+
+    for !id.IsLeaf {
+        if id.Left.IsLeaf && id.Left.Value == 0 {
+            // Case 3: split((0,i)) -> ((0,i1),(0,i2)) where (i1,i2) = split(i)
+            right = append(right, true)
+            id = id.Right
+        } else if id.Right.IsLeaf && id.Right.Value == 0 {
+            // Case 4: split((i,0)) -> ((i1,0),(i2,0)) where (i1,i2) = split(i)
+            right = append(right, false)
+            id = id.Left
+        } else {
+            break
+        }
+    }
+
+    // The leaves 0 and 1 both results share, then two nodes for each level
+    nodes := make([]Id, 2*len(right)+4)
+    zero, one := &nodes[0], &nodes[1]
+    zero.IsLeaf = true
+    one.IsLeaf, one.Value = true, 1
+    nodes = nodes[2:]
+
+    var id1, id2 *Id
+    switch {
+    // Case 1 : split(0) -> (0,0)
+    case id.IsLeaf && id.Value == 0:
+        id1, id2 = zero, zero
+    // Case 2: split(1) -> ((1,0),(0,1))
+    case id.IsLeaf:
+        id1, id2 = &nodes[0], &nodes[1]
+        id1.Left, id1.Right = one, zero
+        id2.Left, id2.Right = zero, one
+        nodes = nodes[2:]
+    // Case 5: split((i1,i2)) -> ((i1,0),(0,i2))
+    default:
+        id1, id2 = &nodes[0], &nodes[1]
+        id1.Left, id1.Right = id.Left, zero
+        id2.Left, id2.Right = zero, id.Right
+        nodes = nodes[2:]
+    }
+
+    for k := len(right) - 1; k >= 0; k-- {
+        n1, n2 := &nodes[0], &nodes[1]
+        nodes = nodes[2:]
+        if right[k] {
+            n1.Left, n1.Right = zero, id1
+            n2.Left, n2.Right = zero, id2
+        } else {
+            n1.Left, n1.Right = id1, zero
+            n2.Left, n2.Right = id2, zero
+        }
+        id1, id2 = n1, n2
+    }
+
+    return id1, id2
+}
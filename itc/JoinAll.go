@@ -0,0 +1,163 @@
+package itc
+
+import (
+    "errors"
+    "fmt"
+)
+
+// ErrOverlappingIds is returned when joining or defragmenting stamps whose ids overlap
+var ErrOverlappingIds = errors.New("itc: ids overlap")
+
+// Join any number of stamps in one pass over their trees, rather than rebuilding and normalising an intermediate
+// stamp for every pair. Nil if there are no stamps. The error names the first two stamps found to overlap.
+func JoinAll(stamps ...*Stamp) (*Stamp, error) {
+    if len(stamps) == 0 {
+        return nil, nil
+    }
+
+    ids := make([]indexedId, len(stamps))
+    events := make([]*Event, len(stamps))
+    for i, s := range stamps {
+        ids[i] = indexedId{s.Id, i}
+        events[i] = s.Event
+    }
+
+    id, err := sumAll(ids)
+    if err != nil {
+        return nil, err
+    }
+
+    return NewStamp(id, JoinEvents(events...)), nil
+}
+
+// Join any number of normalised events in one pass over their trees. Nil if there are no events.
+func JoinEvents(events ...*Event) *Event {
+    if len(events) == 0 {
+        return nil
+    }
+
+    items := make([]liftedEvent, 0, len(events))
+    floor := uint32(0)
+    for _, e := range events {
+        items, floor = collect(items, floor, e, 0)
+    }
+
+    return joinAll(items, floor)
+}
+
+// An event tree to be lifted by offset, standing in for event.Lift(offset) without the copy
+type liftedEvent struct {
+    event  *Event
+    offset uint32
+}
+
+// Add the lifted event to the trees being joined, or to the floor if it is a leaf
+func collect(items []liftedEvent, floor uint32, event *Event, offset uint32) ([]liftedEvent, uint32) {
+    if event.IsLeaf {
+        if v := event.Value + offset; v > floor {
+            floor = v
+        }
+        return items, floor
+    }
+    return append(items, liftedEvent{event, offset}), floor
+}
+
+// Join the lifted trees, none of them a leaf, with the constant floor
+func joinAll(items []liftedEvent, floor uint32) *Event {
+    // Case 1: join(m) -> m
+    if len(items) == 0 {
+        return NewEvent(floor)
+    }
+
+    // Case 2: join((n,l,r),m) -> (n,l,r) where m <= n, as the tree is nowhere below its root
+    if len(items) == 1 && items[0].event.Value+items[0].offset >= floor {
+        return items[0].event.Lift(items[0].offset)
+    }
+
+    // Case 3: join((n1,l1,r1),...,m) -> norm((0,join(l1.Lift(n1),...,m),join(r1.Lift(n1),...,m)))
+    lefts := make([]liftedEvent, 0, len(items))
+    rights := make([]liftedEvent, 0, len(items))
+    lfloor, rfloor := floor, floor
+    for _, item := range items {
+        n := item.event.Value + item.offset
+        lefts, lfloor = collect(lefts, lfloor, item.event.Left, n)
+        rights, rfloor = collect(rights, rfloor, item.event.Right, n)
+    }
+
+    e := &Event{
+        IsLeaf: false,
+        Value: 0,
+        Left: joinAll(lefts, lfloor),
+        Right: joinAll(rights, rfloor),
+    }
+
+    return e.Norm()
+}
+
+// An id and the position of its stamp, for reporting overlaps
+type indexedId struct {
+    id    *Id
+    index int
+}
+
+// Sum the ids, failing if any two overlap
+func sumAll(items []indexedId) (*Id, error) {
+    owned := items[:0:0]
+    var whole *indexedId
+    for i := range items {
+        if items[i].id.IsLeaf {
+            if items[i].id.Value == 0 {
+                continue
+            }
+            whole = &items[i]
+        }
+        owned = append(owned, items[i])
+    }
+
+    // Case 1: sum(0,...,0) -> 0 and sum(0,...,i,...,0) -> i
+    if len(owned) == 0 {
+        return NewId(0), nil
+    }
+    if len(owned) == 1 {
+        return owned[0].id, nil
+    }
+
+    // Case 2: sum(1,i) overlaps unless i is empty
+    if whole != nil {
+        for _, other := range owned {
+            if other.index != whole.index && !other.id.IsEmpty() {
+                i, j := whole.index, other.index
+                if i > j {
+                    i, j = j, i
+                }
+                return nil, fmt.Errorf("%w: stamps %d and %d", ErrOverlappingIds, i, j)
+            }
+        }
+        return whole.id, nil
+    }
+
+    // Case 3: sum((l1,r1),...) -> norm((sum(l1,...),sum(r1,...)))
+    lefts := make([]indexedId, len(owned))
+    rights := make([]indexedId, len(owned))
+    for i, item := range owned {
+        lefts[i] = indexedId{item.id.Left, item.index}
+        rights[i] = indexedId{item.id.Right, item.index}
+    }
+
+    l, err := sumAll(lefts)
+    if err != nil {
+        return nil, err
+    }
+    r, err := sumAll(rights)
+    if err != nil {
+        return nil, err
+    }
+
+    i := &Id{
+        IsLeaf: false,
+        Left: l,
+        Right: r,
+    }
+
+    return i.Norm(), nil
+}
@@ -0,0 +1,116 @@
+package itc
+
+// A run of events a peer has seen and we have not: over Interval, every counter from From to To inclusive. Ask the
+// peer's operation log for the dots in the range.
+type MissingRange struct {
+    // An Id owning a single interval, a path of 0s down to one 1
+    Interval *Id
+    From     uint32
+    To       uint32
+}
+
+// True if the dot falls in the range
+func (r MissingRange) Contains(dot Dot) bool {
+    return !dot.IsZero() && r.From <= dot.Counter && dot.Counter <= r.To && r.Interval.Overlaps(dot.Interval)
+}
+
+// Iterate over the ranges of events in peer that are missing from the event tree, left to right
+//
+//  it := mine.Missing(peer)
+//  for it.Next() {
+//      r := it.Range()
+//      ...
+//  }
+func (event *Event) Missing(peer *Event) *MissingIterator {
+    return &MissingIterator{
+        stack: []missingFrame{{mine: event, peer: peer}},
+    }
+}
+
+type MissingIterator struct {
+    stack   []missingFrame
+    current MissingRange
+}
+
+// A pair of subtrees still to visit. The bases are the sums of the values above each subtree.
+type missingFrame struct {
+    mine     *Event
+    mineBase uint32
+    peer     *Event
+    peerBase uint32
+    path     *pathStep
+}
+
+// The path from the root to a subtree, innermost step first
+type pathStep struct {
+    parent *pathStep
+    right  bool
+}
+
+// Advance to the next range, false when there are none left
+func (it *MissingIterator) Next() bool {
+    for len(it.stack) > 0 {
+        f := it.stack[len(it.stack)-1]
+        it.stack = it.stack[:len(it.stack)-1]
+
+        m := f.mineBase + f.mine.Value
+        p := f.peerBase + f.peer.Value
+
+        // Normalised trees are never below their root value, so a flat peer at or below it is covered
+        if f.peer.IsLeaf && p <= m {
+            continue
+        }
+
+        if f.mine.IsLeaf && f.peer.IsLeaf {
+            it.current = MissingRange{
+                Interval: f.path.interval(),
+                From:     m + 1,
+                To:       p,
+            }
+            return true
+        }
+
+        ml, mlBase, mr, mrBase := f.mine, f.mineBase, f.mine, f.mineBase
+        if !f.mine.IsLeaf {
+            ml, mlBase, mr, mrBase = f.mine.Left, m, f.mine.Right, m
+        }
+        pl, plBase, pr, prBase := f.peer, f.peerBase, f.peer, f.peerBase
+        if !f.peer.IsLeaf {
+            pl, plBase, pr, prBase = f.peer.Left, p, f.peer.Right, p
+        }
+
+        it.stack = append(it.stack,
+            missingFrame{mine: mr, mineBase: mrBase, peer: pr, peerBase: prBase, path: &pathStep{parent: f.path, right: true}},
+            missingFrame{mine: ml, mineBase: mlBase, peer: pl, peerBase: plBase, path: &pathStep{parent: f.path, right: false}},
+        )
+    }
+
+    return false
+}
+
+// The range found by the last call to Next
+func (it *MissingIterator) Range() MissingRange {
+    return it.current
+}
+
+// Collect the remaining ranges
+func (it *MissingIterator) All() []MissingRange {
+    var ranges []MissingRange
+    for it.Next() {
+        ranges = append(ranges, it.Range())
+    }
+    return ranges
+}
+
+// Build the Id owning just the interval at the end of the path
+func (step *pathStep) interval() *Id {
+    id := NewId(1)
+    for s := step; s != nil; s = s.parent {
+        if s.right {
+            id = &Id{IsLeaf: false, Left: NewId(0), Right: id}
+        } else {
+            id = &Id{IsLeaf: false, Left: id, Right: NewId(0)}
+        }
+    }
+    return id
+}
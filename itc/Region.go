@@ -0,0 +1,117 @@
+package itc
+
+// An Id describes a region of the unit interval: the points under its 1 leaves. These functions relate Ids and
+// events through that region.
+
+// True if the id owns no part of the interval
+func (id *Id) IsEmpty() bool {
+    if id.IsLeaf {
+        return id.Value == 0
+    }
+    return id.Left.IsEmpty() && id.Right.IsEmpty()
+}
+
+// True if the two ids own a common part of the interval. Overlapping ids must never be summed.
+func (id1 *Id) Overlaps(id2 *Id) bool {
+    if id1.IsLeaf && id1.Value == 0 || id2.IsLeaf && id2.Value == 0 {
+        return false
+    }
+
+    if id1.IsLeaf {
+        return !id2.IsEmpty()
+    }
+
+    if id2.IsLeaf {
+        return !id1.IsEmpty()
+    }
+
+    return id1.Left.Overlaps(id2.Left) || id1.Right.Overlaps(id2.Right)
+}
+
+// The normalised id owning exactly the part of the interval the id does not
+func (id *Id) Complement() *Id {
+    if id.IsLeaf {
+        return NewId(1 - id.Value)
+    }
+
+    i := &Id{
+        IsLeaf: false,
+        Left: id.Left.Complement(),
+        Right: id.Right.Complement(),
+    }
+
+    return i.Norm()
+}
+
+// The id in normal form. Unlike Norm, every subtree is normalised, so ids built by hand such as ((0,0),1) can be
+// summed.
+func (id *Id) NormDeep() *Id {
+    if id.IsLeaf {
+        return NewId(id.Value)
+    }
+
+    i := &Id{
+        IsLeaf: false,
+        Left: id.Left.NormDeep(),
+        Right: id.Right.NormDeep(),
+    }
+
+    return i.Norm()
+}
+
+// Produce the normalised event that is n over the region of the id and 0 elsewhere
+func EventOver(id *Id, n uint32) *Event {
+    if id.IsLeaf {
+        if id.Value == 0 {
+            return NewEvent(0)
+        }
+        return NewEvent(n)
+    }
+
+    e := &Event{
+        IsLeaf: false,
+        Value: 0,
+        Left: EventOver(id.Left, n),
+        Right: EventOver(id.Right, n),
+    }
+
+    return e.Norm()
+}
+
+// The smallest value the event takes over the region of the id. False if the id is empty.
+func (event *Event) MinOver(id *Id) (uint32, bool) {
+    return event.over(id, Min)
+}
+
+// The largest value the event takes over the region of the id. False if the id is empty.
+func (event *Event) MaxOver(id *Id) (uint32, bool) {
+    return event.over(id, Max)
+}
+
+func (event *Event) over(id *Id, pick func(*Event, *Event) *Event) (uint32, bool) {
+    if id.IsLeaf {
+        if id.Value == 0 {
+            return 0, false
+        }
+        return pick(event, event).Value, true
+    }
+
+    el, er := event, event
+    if !event.IsLeaf {
+        el = event.Left.Lift(event.Value)
+        er = event.Right.Lift(event.Value)
+    }
+
+    l, okl := el.over(id.Left, pick)
+    r, okr := er.over(id.Right, pick)
+
+    switch {
+    case okl && okr:
+        return pick(NewEvent(l), NewEvent(r)).Value, true
+    case okl:
+        return l, true
+    case okr:
+        return r, true
+    }
+    return 0, false
+}
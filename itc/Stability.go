@@ -0,0 +1,196 @@
+package itc
+
+import (
+    "errors"
+    "github.com/gogo/protobuf/proto"
+    "sync"
+)
+
+// ErrUnknownReplica is returned when observing an event from a replica the tracker does not know
+var ErrUnknownReplica = errors.New("itc: unknown replica")
+
+// A StabilityTracker records the latest event seen from each known replica. The meet of those events is the causally
+// stable frontier: everything at or below it has been seen by every replica, so tombstones and log entries under it
+// can be discarded.
+//
+// Subscribers are told whenever the frontier moves, in the order it moved. Frontiers are queued under the tracker's
+// lock and delivered by one goroutine at a time, after the lock is released, so callbacks never run concurrently and
+// may call back into the tracker. An update made while another goroutine is delivering returns at once and leaves its
+// frontier to that goroutine.
+type StabilityTracker struct {
+    mu          sync.Mutex
+    latest      map[string]*Event
+    frontier    *Event
+    subscribers []func(*Event)
+    watchers    map[chan *Event]struct{}
+    pending     []*Event
+    delivering  bool
+}
+
+// Create a tracker for the replicas, all starting from the empty history
+func NewStabilityTracker(replicas ...string) *StabilityTracker {
+    t := &StabilityTracker{
+        latest:   make(map[string]*Event, len(replicas)),
+        watchers: make(map[chan *Event]struct{}),
+    }
+    for _, r := range replicas {
+        t.latest[r] = NewEvent(0)
+    }
+    t.frontier = t.meet()
+
+    return t
+}
+
+// Start tracking a replica. A replica created by forking should be added with the event it was forked with, which
+// keeps the frontier from moving backwards.
+func (t *StabilityTracker) AddReplica(replica string, event *Event) {
+    t.update(func() error {
+        t.latest[replica] = event.Norm()
+        return nil
+    })
+}
+
+// Stop tracking a retired replica. The frontier may move forward.
+func (t *StabilityTracker) RemoveReplica(replica string) {
+    t.update(func() error {
+        delete(t.latest, replica)
+        return nil
+    })
+}
+
+// Record an event seen from the replica. Events only ever add to what is known of a replica.
+func (t *StabilityTracker) Observe(replica string, event *Event) error {
+    return t.update(func() error {
+        e, ok := t.latest[replica]
+        if !ok {
+            return ErrUnknownReplica
+        }
+        t.latest[replica] = e.Join(event)
+        return nil
+    })
+}
+
+// The latest event seen from the replica
+func (t *StabilityTracker) Latest(replica string) (*Event, bool) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    e, ok := t.latest[replica]
+    return e, ok
+}
+
+// The causally stable frontier, nil when no replicas are tracked
+func (t *StabilityTracker) Frontier() *Event {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    return t.frontier
+}
+
+// True if every replica has seen the event
+func (t *StabilityTracker) IsStable(event *Event) bool {
+    f := t.Frontier()
+    return f != nil && event.Leq(f)
+}
+
+// Call fn with the new frontier every time it moves
+func (t *StabilityTracker) Subscribe(fn func(*Event)) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    t.subscribers = append(t.subscribers, fn)
+}
+
+// Receive the frontier on a channel every time it moves. A slow reader only sees the latest frontier. The returned
+// function stops the watch and closes the channel.
+func (t *StabilityTracker) Watch() (<-chan *Event, func()) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    ch := make(chan *Event, 1)
+    t.watchers[ch] = struct{}{}
+
+    var once sync.Once
+    cancel := func() {
+        once.Do(func() {
+            t.mu.Lock()
+            defer t.mu.Unlock()
+
+            delete(t.watchers, ch)
+            close(ch)
+        })
+    }
+
+    return ch, cancel
+}
+
+// Apply the change and notify subscribers if the frontier moved
+func (t *StabilityTracker) update(change func() error) error {
+    t.mu.Lock()
+
+    if err := change(); err != nil {
+        t.mu.Unlock()
+        return err
+    }
+
+    frontier := t.meet()
+    if proto.Equal(frontier, t.frontier) {
+        t.mu.Unlock()
+        return nil
+    }
+    t.frontier = frontier
+
+    for ch := range t.watchers {
+        select {
+        case <-ch:
+        default:
+        }
+        ch <- frontier
+    }
+
+    t.pending = append(t.pending, frontier)
+    if t.delivering {
+        t.mu.Unlock()
+        return nil
+    }
+    t.delivering = true
+
+    // A panicking callback must not leave the tracker delivering, or no update would deliver again
+    locked := true
+    defer func() {
+        if !locked {
+            t.mu.Lock()
+        }
+        t.delivering = false
+        t.mu.Unlock()
+    }()
+
+    for len(t.pending) > 0 {
+        pending := t.pending
+        t.pending = nil
+        subscribers := make([]func(*Event), len(t.subscribers))
+        copy(subscribers, t.subscribers)
+        t.mu.Unlock()
+        locked = false
+
+        for _, f := range pending {
+            for _, fn := range subscribers {
+                fn(f)
+            }
+        }
+
+        t.mu.Lock()
+        locked = true
+    }
+
+    return nil
+}
+
+func (t *StabilityTracker) meet() *Event {
+    events := make([]*Event, 0, len(t.latest))
+    for _, e := range t.latest {
+        events = append(events, e)
+    }
+
+    return MeetAll(events...)
+}
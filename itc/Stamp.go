@@ -31,7 +31,7 @@ func (s1 *Stamp) Leq(s2 *Stamp) bool {
 
     // Case 4: leq((n1,l1,r1),(n2,l2,r2)) -> n1 <= n2 AND l1.Lift(n1).Leq(l2.Lift(n2)) AND r1.Lift(n1).Leq(r2.Lift(n2)
     if !s1.Event.IsLeaf && !s2.Event.IsLeaf {
-        return s1.Event.Value <= s2.Event.Value && s1.Event.Left.Lift(s1.Event.Value).Leq(s2.Event.Lift(s2.Event.Value)) && s1.Event.Right.Lift(s1.Event.Value).Leq(s2.Event.Right.Lift(s2.Event.Value))
+        return s1.Event.Value <= s2.Event.Value && s1.Event.Left.Lift(s1.Event.Value).Leq(s2.Event.Left.Lift(s2.Event.Value)) && s1.Event.Right.Lift(s1.Event.Value).Leq(s2.Event.Right.Lift(s2.Event.Value))
     }
 
     return false
@@ -64,6 +64,17 @@ func (s1 *Stamp) Join(s2 *Stamp) *Stamp {
     return stamp
 }
 
+// Meet of the event trees of the stamps, nil if there are none. Ids play no part: this is the history every
+// stamp has seen, such as the causally stable frontier across replicas.
+func MeetStamps(stamps ...*Stamp) *Event {
+    events := make([]*Event, len(stamps))
+    for i, s := range stamps {
+        events[i] = s.Event
+    }
+
+    return MeetAll(events...)
+}
+
 // Section 5.3.4 During Advance, attempt to simplify the event tree
 func (stamp *Stamp) Fill() *Event {
     // Case 1: fill(0,e) -> e
@@ -89,7 +100,7 @@ func (stamp *Stamp) Fill() *Event {
     }
 
     // Case 4: fill((1,ir),(n,el,er)) -> norm((n,max(max(el),min(erprime)),erprime)) where erprime = fill(ir,er)
-    if !stamp.Id.IsLeaf && stamp.Id.Left.Value ==1 && !stamp.Event.IsLeaf {
+    if !stamp.Id.IsLeaf && stamp.Id.Left.IsLeaf && stamp.Id.Left.Value ==1 && !stamp.Event.IsLeaf {
         s := &Stamp{
             Id:stamp.Id.Right.Copy(),
             Event:stamp.Event.Right.Copy(),
@@ -108,7 +119,7 @@ func (stamp *Stamp) Fill() *Event {
     }
 
     // Case 5: fill((il,1),(n,el,er)) -> norm((n,elprime,max(max(er),min(elprime)))) where elprime=fill(il,el)
-    if !stamp.Id.IsLeaf && stamp.Id.Right.Value ==1 && !stamp.Event.IsLeaf {
+    if !stamp.Id.IsLeaf && stamp.Id.Right.IsLeaf && stamp.Id.Right.Value ==1 && !stamp.Event.IsLeaf {
         s := &Stamp{
             Id:stamp.Id.Left.Copy(),
             Event:stamp.Event.Left.Copy(),
@@ -126,6 +137,27 @@ func (stamp *Stamp) Fill() *Event {
         return e.Norm()
     }
 
+    // Case 6: fill((il,ir),(n,el,er)) -> norm((n,fill(il,el),fill(ir,er)))
+    if !stamp.Id.IsLeaf && !stamp.Event.IsLeaf {
+        sl := &Stamp{
+            Id: stamp.Id.Left,
+            Event: stamp.Event.Left,
+        }
+        sr := &Stamp{
+            Id: stamp.Id.Right,
+            Event: stamp.Event.Right,
+        }
+
+        e := &Event{
+            IsLeaf: false,
+            Value: stamp.Event.Value,
+            Left: sl.Fill(),
+            Right: sr.Fill(),
+        }
+
+        return e.Norm()
+    }
+
     // Error
     return nil
 }
@@ -133,6 +165,11 @@ func (stamp *Stamp) Fill() *Event {
 // Section 5.3.4 During Advance, when fill is not possible, grow the event tree
 // Returns a new event tree and a cost for that tree
 func (stamp *Stamp) Grow() (*Event,uint32) {
+    return stamp.GrowWith(DefaultGrowPolicy)
+}
+
+// Grow the event tree where the policy finds it cheapest
+func (stamp *Stamp) GrowWith(policy GrowPolicy) (*Event,uint32) {
 
     // Case 1: grow(1,n) -> (n+1,0)
     if stamp.Id.IsLeaf && stamp.Id.Value ==1 && stamp.Event.IsLeaf {
@@ -143,7 +180,7 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
         return e,0
     }
 
-    // Case 2: grow(i,n) -> (eprime,c + N) where (eprime,c) = grow(i,(n,0,0))
+    // Case 2: grow(i,n) -> (eprime,expand(c)) where (eprime,c) = grow(i,(n,0,0))
     if !stamp.Id.IsLeaf && stamp.Event.IsLeaf {
         el := &Event{
             IsLeaf: true,
@@ -162,18 +199,18 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
             Event: e,
         }
 
-        eprime,c := s.Grow()
+        eprime,c := s.GrowWith(policy)
 
-        return eprime,c+GrowIncrement
+        return eprime,policy.Expand(c)
     }
 
-    // Case 3: grow((0,ir),(n,el,er)) -> ((n,el,erprime),cr+1) where (erprime,cr) = grow(ir,er)
+    // Case 3: grow((0,ir),(n,el,er)) -> ((n,el,erprime),descend(cr)) where (erprime,cr) = grow(ir,er)
     if !stamp.Id.IsLeaf && stamp.Id.Left.IsLeaf && stamp.Id.Left.Value == 0 && !stamp.Event.IsLeaf {
         s := &Stamp{
             Id: stamp.Id.Right,
             Event: stamp.Event.Right,
         }
-        erprime,cr := s.Grow()
+        erprime,cr := s.GrowWith(policy)
 
         e := &Event{
             IsLeaf: false,
@@ -182,16 +219,16 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
             Right: erprime,
         }
 
-        return e,cr+1
+        return e,policy.Descend(cr)
     }
 
-    // Case 4: grow((il,0),(n,el,er)) -> ((n,elprime,er),cl+1) where (elprime,cl) = grow(il,el)
+    // Case 4: grow((il,0),(n,el,er)) -> ((n,elprime,er),descend(cl)) where (elprime,cl) = grow(il,el)
     if !stamp.Id.IsLeaf && stamp.Id.Right.IsLeaf && stamp.Id.Right.Value == 0 && !stamp.Event.IsLeaf {
         s := &Stamp{
             Id: stamp.Id.Left,
             Event: stamp.Event.Left,
         }
-        elprime,cl := s.Grow()
+        elprime,cl := s.GrowWith(policy)
 
         e := &Event{
             IsLeaf: false,
@@ -200,12 +237,13 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
             Right: stamp.Event.Right.Copy(),
         }
 
-        return e,cl+1
+        return e,policy.Descend(cl)
     }
 
     // Case 5: grow((il,ir),(n,el,er)) ->
-    // ((n,elprime,er),cl+1)    if cl < cr
-    // ((n,el,erprime),cr+1)    if cl >= cr
+    // ((n,elprime,er),descend(cl))    if cl < cr
+    // ((n,el,erprime),descend(cr))    if cl >= cr
+    // or as a WeighingGrowPolicy chooses
     // where (elprime,cl) = grow(il,el)
     // and (erprime,cr) = grow(ir,er)
     if !stamp.Id.IsLeaf && !stamp.Event.IsLeaf {
@@ -213,15 +251,15 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
             Id: stamp.Id.Left,
             Event: stamp.Event.Left,
         }
-        elprime,cl := sl.Grow()
+        elprime,cl := sl.GrowWith(policy)
 
         sr := &Stamp{
             Id: stamp.Id.Right,
             Event : stamp.Event.Right,
         }
-        erprime,cr := sr.Grow()
+        erprime,cr := sr.GrowWith(policy)
 
-        if cl < cr {
+        if growLeft(policy, cl, cr, stamp.Id.Left, stamp.Id.Right) {
             e := &Event{
                 IsLeaf: false,
                 Value: stamp.Event.Value,
@@ -229,7 +267,7 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
                 Right: stamp.Event.Right,
             }
 
-            return e,cl+1
+            return e,policy.Descend(cl)
         } else {
             e := &Event{
                 IsLeaf: false,
@@ -238,7 +276,7 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
                 Right: erprime,
             }
 
-            return e,cr+1
+            return e,policy.Descend(cr)
         }
     }
 
@@ -248,16 +286,40 @@ func (stamp *Stamp) Grow() (*Event,uint32) {
 // Section 5.3.4 Advance
 // Called "Event" in the document but renamed to avoid name collision
 func (stamp *Stamp) Advance() *Stamp {
+    return stamp.AdvanceWith(DefaultGrowPolicy)
+}
+
+// Advance, growing the event tree where the policy finds it cheapest when it cannot be filled
+func (stamp *Stamp) AdvanceWith(policy GrowPolicy) *Stamp {
     e := stamp.Fill()
 
     if !proto.Equal(e,stamp.Event){
-        return stamp.Copy()
+        return NewStamp(stamp.Id,e)
     } else {
-        e,_ := stamp.Grow()
+        e,_ := stamp.GrowWith(policy)
         return NewStamp(stamp.Id,e)
     }
 }
 
+// Section 5.3.5 Peek produces an anonymous stamp with the same event tree, to be sent with a message, and the stamp
+// itself, which stays with the sender
+func (stamp *Stamp) Peek() (*Stamp, *Stamp) {
+    anonymous := NewStamp(NewId(0),stamp.Event)
+
+    return anonymous,stamp.Copy()
+}
+
+// Section 5.3.5 Send records the event of sending a message: the peek of the advanced stamp
+func (stamp *Stamp) Send() (*Stamp, *Stamp) {
+    return stamp.Advance().Peek()
+}
+
+// Section 5.3.5 Receive a message by joining its stamp and recording the event of receiving it. Messages delivered by
+// a CausalBuffer are received with the function Receive instead.
+func (stamp *Stamp) Receive(message *Stamp) *Stamp {
+    return stamp.Join(message).Advance()
+}
+
 // Produce a shallow copy of the stamp
 func (stamp *Stamp) Copy() *Stamp {
     s := Stamp{
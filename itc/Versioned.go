@@ -0,0 +1,88 @@
+package itc
+
+// A value together with the stamp of the write that produced it
+type Version[T any] struct {
+    Value T
+    Stamp *Stamp
+}
+
+// A Versioned holds the causally concurrent versions of a value, in the style of dotted version vectors. Versions
+// dominated under Leq by another version are discarded as they arrive, the rest are kept as siblings for the
+// application to resolve. A Versioned is immutable: every operation returns a new one.
+type Versioned[T any] struct {
+    siblings []Version[T]
+}
+
+// Create an empty container
+func NewVersioned[T any]() *Versioned[T] {
+    return &Versioned[T]{}
+}
+
+// Add a version. It is dropped if an existing sibling dominates it, otherwise it replaces the siblings it dominates.
+func (v *Versioned[T]) Put(value T, stamp *Stamp) *Versioned[T] {
+    return v.add(Version[T]{Value: value, Stamp: stamp})
+}
+
+// Merge the siblings of another container
+func (v *Versioned[T]) Merge(other *Versioned[T]) *Versioned[T] {
+    merged := v
+    for _, version := range other.siblings {
+        merged = merged.add(version)
+    }
+    return merged
+}
+
+// Write a value that supersedes every current sibling. The writer's stamp is joined with the siblings' history and
+// advanced, and the advanced stamp is returned for the writer to keep.
+func (v *Versioned[T]) Resolve(value T, stamp *Stamp) (*Versioned[T], *Stamp) {
+    next := NewStamp(stamp.Id, stamp.Event.Join(v.Context())).Advance()
+    return &Versioned[T]{siblings: []Version[T]{{Value: value, Stamp: next}}}, next
+}
+
+// The current siblings in the order they arrived
+func (v *Versioned[T]) Siblings() []Version[T] {
+    siblings := make([]Version[T], len(v.siblings))
+    copy(siblings, v.siblings)
+    return siblings
+}
+
+// The sibling values in the order they arrived
+func (v *Versioned[T]) Values() []T {
+    values := make([]T, len(v.siblings))
+    for i, s := range v.siblings {
+        values[i] = s.Value
+    }
+    return values
+}
+
+// True if there is more than one sibling
+func (v *Versioned[T]) Conflict() bool {
+    return len(v.siblings) > 1
+}
+
+// The join of the siblings' event trees: the history a write must have seen to supersede them all
+func (v *Versioned[T]) Context() *Event {
+    e := NewEvent(0)
+    for _, s := range v.siblings {
+        e = e.Join(s.Stamp.Event)
+    }
+    return e
+}
+
+func (v *Versioned[T]) add(version Version[T]) *Versioned[T] {
+    for _, s := range v.siblings {
+        if version.Stamp.Leq(s.Stamp) {
+            return v
+        }
+    }
+
+    siblings := make([]Version[T], 0, len(v.siblings)+1)
+    for _, s := range v.siblings {
+        if !s.Stamp.Leq(version.Stamp) {
+            siblings = append(siblings, s)
+        }
+    }
+    siblings = append(siblings, version)
+
+    return &Versioned[T]{siblings: siblings}
+}
@@ -0,0 +1,163 @@
+// Package coordinator hands out disjoint ITC ids from a single authority.
+//
+// Forking a stamp is only safe when the forked stamp is not forked again concurrently elsewhere; otherwise two
+// replicas can end up owning overlapping ids. A Coordinator holds the seed id, splits off a fresh share for each
+// replica that joins and sums the replica's Id back in when it leaves, persisting its state before answering.
+// Replicas reach it through the Leaser interface, either in process or over the wire protocol served by ServeConn.
+//
+// The coordinator only manages ids. A replica that acquires an id still has to obtain a current event tree from its
+// peers before it starts recording events.
+package coordinator
+
+import (
+    "errors"
+    "github.com/ziglet.io/go-itc/itc"
+    "github.com/ziglet.io/go-itc/itc/internal/wire"
+    "sort"
+    "sync"
+)
+
+var (
+    // ErrUnknownReplica is returned when releasing an id the coordinator never leased
+    ErrUnknownReplica = errors.New("coordinator: unknown replica")
+    // ErrOverlap is returned when a released id overlaps the free id
+    ErrOverlap = errors.New("coordinator: released id overlaps the free id")
+    // ErrExhausted is returned when the coordinator holds no id to split
+    ErrExhausted = errors.New("coordinator: no id left to lease")
+    // ErrClosed is returned by a client whose connection is closed
+    ErrClosed = errors.New("coordinator: closed")
+    // ErrFrameTooLarge is returned when a frame of the wire protocol exceeds the size limit
+    ErrFrameTooLarge = wire.ErrFrameTooLarge
+)
+
+// Acquire and release ids for named replicas
+type Leaser interface {
+    // Lease an id to the replica. Acquiring again before releasing returns the same id.
+    Acquire(replica string) (*itc.Id, error)
+    // Return the replica's current id to the coordinator, which may differ from its lease if the replica exchanged ids
+    // with its peers since
+    Release(replica string, id *itc.Id) error
+}
+
+var _ Leaser = (*Coordinator)(nil)
+
+type Coordinator struct {
+    mu     sync.Mutex
+    store  Store
+    free   *itc.Id
+    leases map[string]*itc.Id
+}
+
+// Create a coordinator backed by the store. A store without saved state starts the coordinator with the seed id.
+func NewCoordinator(store Store) (*Coordinator, error) {
+    c := &Coordinator{
+        store:  store,
+        free:   itc.NewId(1),
+        leases: make(map[string]*itc.Id),
+    }
+
+    b, err := store.Load()
+    if err != nil {
+        return nil, err
+    }
+    if b != nil {
+        if c.free, c.leases, err = decodeState(b); err != nil {
+            return nil, err
+        }
+    }
+
+    return c, nil
+}
+
+// Split the free id, leasing one half to the replica and keeping the other
+func (c *Coordinator) Acquire(replica string) (*itc.Id, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if id, ok := c.leases[replica]; ok {
+        return id.Copy(), nil
+    }
+    if c.free.IsEmpty() {
+        return nil, ErrExhausted
+    }
+
+    lease, free := c.free.Split()
+    if err := c.commit(free, replica, lease); err != nil {
+        return nil, err
+    }
+
+    return lease.Copy(), nil
+}
+
+// Sum the replica's current id back into the free id and drop its lease
+func (c *Coordinator) Release(replica string, id *itc.Id) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if _, ok := c.leases[replica]; !ok {
+        return ErrUnknownReplica
+    }
+    if id.Overlaps(c.free) {
+        return ErrOverlap
+    }
+
+    return c.commit(c.free.Sum(id.NormDeep()), replica, nil)
+}
+
+// The id not leased to any replica
+func (c *Coordinator) Free() *itc.Id {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    return c.free.Copy()
+}
+
+// The replicas holding a lease, sorted
+func (c *Coordinator) Replicas() []string {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    replicas := make([]string, 0, len(c.leases))
+    for r := range c.leases {
+        replicas = append(replicas, r)
+    }
+    sort.Strings(replicas)
+
+    return replicas
+}
+
+// The id leased to the replica, or nil
+func (c *Coordinator) Lease(replica string) *itc.Id {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if id, ok := c.leases[replica]; ok {
+        return id.Copy()
+    }
+    return nil
+}
+
+// Persist the state with the free id and the replica's lease replaced, a nil lease removing it, then apply it. Nothing
+// changes if the store fails.
+func (c *Coordinator) commit(free *itc.Id, replica string, lease *itc.Id) error {
+    leases := make(map[string]*itc.Id, len(c.leases)+1)
+    for r, id := range c.leases {
+        leases[r] = id
+    }
+    if lease == nil {
+        delete(leases, replica)
+    } else {
+        leases[replica] = lease
+    }
+
+    b, err := encodeState(free, leases)
+    if err != nil {
+        return err
+    }
+    if err := c.store.Save(b); err != nil {
+        return err
+    }
+
+    c.free, c.leases = free, leases
+    return nil
+}
@@ -0,0 +1,198 @@
+package coordinator
+
+import (
+    "errors"
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/itc"
+    "github.com/ziglet.io/go-itc/itc/internal/wire"
+    "io"
+    "net"
+    "sync"
+)
+
+// ErrMalformed is returned for a frame that does not decode
+var ErrMalformed = errors.New("coordinator: malformed frame")
+
+type Options struct {
+    // Largest frame accepted, wire.DefaultMaxFrame if 0
+    MaxFrame int
+}
+
+// Frames are sent with wire.WriteFrame. A request is an op, the replica name and, for a release, the protobuf Id
+// released. A response is a status and, for a successful acquire the protobuf Id or for an error its message. Strings
+// and ids are prefixed with their uvarint length.
+const (
+    opAcquire byte = iota + 1
+    opRelease
+)
+
+const (
+    statusOk byte = iota
+    statusUnknownReplica
+    statusExhausted
+    statusError
+    statusOverlap
+)
+
+// Answer requests from one client until the connection is closed. Returns nil when the client hangs up between
+// requests.
+func ServeConn(rw io.ReadWriter, leaser Leaser, options Options) error {
+    maxFrame := wire.FrameLimit(options.MaxFrame)
+    for {
+        body, _, err := wire.ReadFrame(rw, maxFrame)
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if len(body) < 1 {
+            return ErrMalformed
+        }
+        replica, rest, err := wire.ReadBytes(body[1:])
+        if err != nil {
+            return ErrMalformed
+        }
+        encoded, rest, err := wire.ReadBytes(rest)
+        if err != nil || len(rest) != 0 {
+            return ErrMalformed
+        }
+
+        var payload []byte
+        switch body[0] {
+        case opAcquire:
+            var id *itc.Id
+            if id, err = leaser.Acquire(string(replica)); err == nil {
+                payload, err = proto.Marshal(id)
+            }
+        case opRelease:
+            id := &itc.Id{}
+            if proto.Unmarshal(encoded, id) != nil {
+                return ErrMalformed
+            }
+            err = leaser.Release(string(replica), id)
+        default:
+            return ErrMalformed
+        }
+
+        status := statusOk
+        switch {
+        case err == ErrUnknownReplica:
+            status = statusUnknownReplica
+        case err == ErrExhausted:
+            status = statusExhausted
+        case err == ErrOverlap:
+            status = statusOverlap
+        case err != nil:
+            status, payload = statusError, []byte(err.Error())
+        }
+        if _, err := wire.WriteFrame(rw, wire.AppendBytes([]byte{status}, payload)); err != nil {
+            return err
+        }
+    }
+}
+
+// A Leaser speaking the wire protocol to a coordinator. Requests from several goroutines are sent one at a time.
+type Client struct {
+    mu       sync.Mutex
+    conn     io.ReadWriteCloser
+    maxFrame int
+    closed   bool
+}
+
+var _ Leaser = (*Client)(nil)
+
+func NewClient(conn io.ReadWriteCloser, options Options) *Client {
+    return &Client{conn: conn, maxFrame: wire.FrameLimit(options.MaxFrame)}
+}
+
+// A client connected to the coordinator in process over net.Pipe, served until the client is closed
+func NewLocalClient(leaser Leaser, options Options) *Client {
+    client, server := net.Pipe()
+    go func() {
+        ServeConn(server, leaser, options)
+        server.Close()
+    }()
+
+    return NewClient(client, options)
+}
+
+func (c *Client) Acquire(replica string) (*itc.Id, error) {
+    payload, err := c.call(opAcquire, replica, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    id := &itc.Id{}
+    if err := proto.Unmarshal(payload, id); err != nil {
+        return nil, ErrMalformed
+    }
+    return id, nil
+}
+
+func (c *Client) Release(replica string, id *itc.Id) error {
+    encoded, err := proto.Marshal(id)
+    if err != nil {
+        return err
+    }
+    _, err = c.call(opRelease, replica, encoded)
+    return err
+}
+
+func (c *Client) Close() error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if c.closed {
+        return nil
+    }
+    c.closed = true
+    return c.conn.Close()
+}
+
+func (c *Client) call(op byte, replica string, id []byte) ([]byte, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if c.closed {
+        return nil, ErrClosed
+    }
+    request := wire.AppendBytes(wire.AppendBytes([]byte{op}, []byte(replica)), id)
+    if _, err := wire.WriteFrame(c.conn, request); err != nil {
+        c.fail()
+        return nil, err
+    }
+    body, _, err := wire.ReadFrame(c.conn, c.maxFrame)
+    if err != nil {
+        // The response may still arrive and would be read as the answer to the next request
+        c.fail()
+        return nil, err
+    }
+    if len(body) < 1 {
+        return nil, ErrMalformed
+    }
+    payload, rest, err := wire.ReadBytes(body[1:])
+    if err != nil || len(rest) != 0 {
+        return nil, ErrMalformed
+    }
+
+    switch body[0] {
+    case statusOk:
+        return payload, nil
+    case statusUnknownReplica:
+        return nil, ErrUnknownReplica
+    case statusExhausted:
+        return nil, ErrExhausted
+    case statusOverlap:
+        return nil, ErrOverlap
+    case statusError:
+        return nil, errors.New(string(payload))
+    }
+    return nil, ErrMalformed
+}
+
+// Close the connection after a failed request, leaving the client closed. Called with the lock held.
+func (c *Client) fail() {
+    c.closed = true
+    c.conn.Close()
+}
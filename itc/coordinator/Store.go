@@ -0,0 +1,131 @@
+package coordinator
+
+import (
+    "bytes"
+    "encoding/gob"
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/itc"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+// Durable storage for the coordinator's state. Save must not return until the state would survive a crash.
+type Store interface {
+    // The last saved state, or nil if nothing was saved
+    Load() ([]byte, error)
+    Save(state []byte) error
+}
+
+// A Store in memory, for tests and for coordinators that do not need to survive a restart
+type MemoryStore struct {
+    mu    sync.Mutex
+    state []byte
+}
+
+func NewMemoryStore() *MemoryStore {
+    return &MemoryStore{}
+}
+
+func (s *MemoryStore) Load() ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    return s.state, nil
+}
+
+func (s *MemoryStore) Save(state []byte) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.state = append([]byte(nil), state...)
+    return nil
+}
+
+// A Store in a single file, replaced atomically on every save
+type FileStore struct {
+    Path string
+}
+
+func NewFileStore(path string) *FileStore {
+    return &FileStore{Path: path}
+}
+
+func (s *FileStore) Load() ([]byte, error) {
+    b, err := os.ReadFile(s.Path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    return b, err
+}
+
+// Write to a temporary file in the same directory, sync it and rename it over the old state
+func (s *FileStore) Save(state []byte) error {
+    f, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(f.Name())
+
+    if _, err := f.Write(state); err != nil {
+        f.Close()
+        return err
+    }
+    if err := f.Sync(); err != nil {
+        f.Close()
+        return err
+    }
+    if err := f.Close(); err != nil {
+        return err
+    }
+
+    return os.Rename(f.Name(), s.Path)
+}
+
+// State is encoded with encoding/gob, the ids inside it keeping their protobuf encoding
+type state struct {
+    Free   []byte
+    Leases map[string][]byte
+}
+
+func encodeState(free *itc.Id, leases map[string]*itc.Id) ([]byte, error) {
+    s := state{Leases: make(map[string][]byte, len(leases))}
+
+    var err error
+    if s.Free, err = proto.Marshal(free); err != nil {
+        return nil, err
+    }
+    for r, id := range leases {
+        if s.Leases[r], err = proto.Marshal(id); err != nil {
+            return nil, err
+        }
+    }
+
+    var buf bytes.Buffer
+    if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+func decodeState(b []byte) (*itc.Id, map[string]*itc.Id, error) {
+    var s state
+    if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&s); err != nil {
+        return nil, nil, err
+    }
+
+    free := &itc.Id{}
+    if err := proto.Unmarshal(s.Free, free); err != nil {
+        return nil, nil, err
+    }
+    leases := make(map[string]*itc.Id, len(s.Leases))
+    for r, encoded := range s.Leases {
+        id := &itc.Id{}
+        if err := proto.Unmarshal(encoded, id); err != nil {
+            return nil, nil, err
+        }
+        leases[r] = id
+    }
+
+    return free, leases, nil
+}
@@ -0,0 +1,14 @@
+// Package crdt provides conflict-free replicated data types whose causality is tracked with Interval Tree Clocks.
+//
+// Every replica keeps its own *itc.Stamp next to the data. Updates take the replica's stamp and return the advanced
+// stamp alongside the new state. All types are immutable and merge with Merge.
+package crdt
+
+import "errors"
+
+var (
+    // ErrMalformed is returned when decoding bytes that were not produced by Encode
+    ErrMalformed = errors.New("crdt: malformed encoding")
+    // ErrAnonymous is returned for an update that must be tagged with an event, made with an anonymous stamp
+    ErrAnonymous = errors.New("crdt: anonymous stamp cannot tag an update")
+)
@@ -0,0 +1,55 @@
+package crdt
+
+import (
+    "bytes"
+    "encoding/gob"
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/itc"
+)
+
+// CRDT state is encoded with encoding/gob so that any gob-encodable value type can be stored. Stamps and events inside
+// the state keep their protobuf encoding.
+
+func encodeGob(v any) ([]byte, error) {
+    var buf bytes.Buffer
+    if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+func decodeGob(b []byte, v any) error {
+    return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+func marshalEvent(e *itc.Event) ([]byte, error) {
+    if e == nil {
+        return nil, nil
+    }
+    return proto.Marshal(e)
+}
+
+func unmarshalEvent(b []byte) (*itc.Event, error) {
+    if b == nil {
+        return nil, nil
+    }
+    e := &itc.Event{}
+    if err := proto.Unmarshal(b, e); err != nil {
+        return nil, err
+    }
+    return e, nil
+}
+
+func marshalStamp(s *itc.Stamp) ([]byte, error) {
+    if s == nil {
+        return nil, nil
+    }
+    return s.Encode()
+}
+
+func unmarshalStamp(b []byte) (*itc.Stamp, error) {
+    if b == nil {
+        return nil, nil
+    }
+    return itc.DecodeStamp(b)
+}
@@ -0,0 +1,109 @@
+package crdt
+
+import "github.com/ziglet.io/go-itc/itc"
+
+// A last-writer-wins register. A write that causally follows another wins. Concurrent writes are settled
+// deterministically by the Ids of their writers, so every replica picks the same winner.
+type LWWRegister[T any] struct {
+    value   T
+    write   *itc.Stamp
+    context *itc.Event
+}
+
+func NewLWWRegister[T any]() *LWWRegister[T] {
+    return &LWWRegister[T]{context: itc.NewEvent(0)}
+}
+
+// Write a value at the replica owning stamp. Returns the new register and the replica's advanced stamp. An anonymous
+// stamp cannot tag the write, so the register and stamp come back unchanged.
+func (r *LWWRegister[T]) Set(stamp *itc.Stamp, value T) (*LWWRegister[T], *itc.Stamp) {
+    if stamp.Id.IsEmpty() {
+        return r, stamp
+    }
+    next := itc.NewStamp(stamp.Id, stamp.Event.Join(r.context)).Advance()
+    return &LWWRegister[T]{value: value, write: next, context: next.Event}, next
+}
+
+// The winning value, false if the register was never written
+func (r *LWWRegister[T]) Get() (T, bool) {
+    return r.value, r.write != nil
+}
+
+// The stamp of the winning write, nil if the register was never written
+func (r *LWWRegister[T]) Write() *itc.Stamp {
+    return r.write
+}
+
+// Merge the state of another replica
+func (r *LWWRegister[T]) Merge(other *LWWRegister[T]) *LWWRegister[T] {
+    winner := r
+    if wins(other.write, r.write) {
+        winner = other
+    }
+
+    return &LWWRegister[T]{
+        value:   winner.value,
+        write:   winner.write,
+        context: r.context.Join(other.context),
+    }
+}
+
+// The history of every write the register has seen
+func (r *LWWRegister[T]) Context() *itc.Event {
+    return r.context
+}
+
+// True if write a beats write b
+func wins(a *itc.Stamp, b *itc.Stamp) bool {
+    switch {
+    case a == nil:
+        return false
+    case b == nil:
+        return true
+    case a.Leq(b):
+        return false
+    case b.Leq(a):
+        return true
+    }
+
+    // Concurrent writers never share an Id
+    return a.Id.Print() > b.Id.Print()
+}
+
+type lwwRegisterWire[T any] struct {
+    Value   T
+    Write   []byte
+    Context []byte
+}
+
+func (r *LWWRegister[T]) Encode() ([]byte, error) {
+    write, err := marshalStamp(r.write)
+    if err != nil {
+        return nil, err
+    }
+    context, err := marshalEvent(r.context)
+    if err != nil {
+        return nil, err
+    }
+    return encodeGob(lwwRegisterWire[T]{Value: r.value, Write: write, Context: context})
+}
+
+func DecodeLWWRegister[T any](b []byte) (*LWWRegister[T], error) {
+    w := lwwRegisterWire[T]{}
+    if err := decodeGob(b, &w); err != nil {
+        return nil, err
+    }
+
+    write, err := unmarshalStamp(w.Write)
+    if err != nil {
+        return nil, err
+    }
+    context, err := unmarshalEvent(w.Context)
+    if err != nil {
+        return nil, err
+    }
+    if context == nil {
+        return nil, ErrMalformed
+    }
+    return &LWWRegister[T]{value: w.Value, write: write, context: context}, nil
+}
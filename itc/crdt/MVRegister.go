@@ -0,0 +1,84 @@
+package crdt
+
+import "github.com/ziglet.io/go-itc/itc"
+
+// A multi-value register keeps every causally concurrent write. A write supersedes all the values it has seen, so
+// after replicas sync there is one value per concurrent write that has not been overwritten.
+type MVRegister[T any] struct {
+    versions *itc.Versioned[T]
+}
+
+func NewMVRegister[T any]() *MVRegister[T] {
+    return &MVRegister[T]{versions: itc.NewVersioned[T]()}
+}
+
+// Write a value at the replica owning stamp. Returns the new register and the replica's advanced stamp. An anonymous
+// stamp cannot tag the write, so the register and stamp come back unchanged.
+func (r *MVRegister[T]) Set(stamp *itc.Stamp, value T) (*MVRegister[T], *itc.Stamp) {
+    if stamp.Id.IsEmpty() {
+        return r, stamp
+    }
+    versions, next := r.versions.Resolve(value, stamp)
+    return &MVRegister[T]{versions: versions}, next
+}
+
+// The concurrent values, empty if the register was never written
+func (r *MVRegister[T]) Values() []T {
+    return r.versions.Values()
+}
+
+// The concurrent writes with their stamps
+func (r *MVRegister[T]) Versions() []itc.Version[T] {
+    return r.versions.Siblings()
+}
+
+// Merge the state of another replica
+func (r *MVRegister[T]) Merge(other *MVRegister[T]) *MVRegister[T] {
+    return &MVRegister[T]{versions: r.versions.Merge(other.versions)}
+}
+
+// The history of the writes the register holds
+func (r *MVRegister[T]) Context() *itc.Event {
+    return r.versions.Context()
+}
+
+type mvRegisterWire[T any] struct {
+    Values []T
+    Stamps [][]byte
+}
+
+func (r *MVRegister[T]) Encode() ([]byte, error) {
+    w := mvRegisterWire[T]{}
+    for _, v := range r.versions.Siblings() {
+        b, err := marshalStamp(v.Stamp)
+        if err != nil {
+            return nil, err
+        }
+        w.Values = append(w.Values, v.Value)
+        w.Stamps = append(w.Stamps, b)
+    }
+    return encodeGob(w)
+}
+
+func DecodeMVRegister[T any](b []byte) (*MVRegister[T], error) {
+    w := mvRegisterWire[T]{}
+    if err := decodeGob(b, &w); err != nil {
+        return nil, err
+    }
+    if len(w.Values) != len(w.Stamps) {
+        return nil, ErrMalformed
+    }
+
+    versions := itc.NewVersioned[T]()
+    for i, v := range w.Values {
+        s, err := unmarshalStamp(w.Stamps[i])
+        if err != nil {
+            return nil, err
+        }
+        if s == nil {
+            return nil, ErrMalformed
+        }
+        versions = versions.Put(v, s)
+    }
+    return &MVRegister[T]{versions: versions}, nil
+}
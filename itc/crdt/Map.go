@@ -0,0 +1,256 @@
+package crdt
+
+import (
+    "encoding/gob"
+    "errors"
+    "github.com/ziglet.io/go-itc/itc"
+    "sort"
+)
+
+// ErrTypeMismatch is returned when merging two different kinds of CRDT under the same map key
+var ErrTypeMismatch = errors.New("crdt: cannot merge different kinds of value")
+
+// A CRDT that can be nested in a Map: *MVRegister, *LWWRegister, *ORSet, *PNCounter or *Map
+type Value interface {
+    Encode() ([]byte, error)
+    mergeValue(other Value) (Value, error)
+}
+
+// A map from string keys to nested CRDTs. The keys form an add-wins set. Values under the same key are merged.
+//
+// Removing a key drops its value locally. A replica that updated the key concurrently keeps it alive and brings
+// its own state of the value back with it.
+//
+// Maps are encoded with encoding/gob. Values of the generic types must be registered before a map holding them is
+// decoded, for example gob.Register(crdt.NewMVRegister[string]()).
+type Map struct {
+    keys   *ORSet[string]
+    values map[string]Value
+}
+
+func init() {
+    gob.Register(NewPNCounter())
+    gob.Register(NewMap())
+}
+
+func NewMap() *Map {
+    return &Map{keys: NewORSet[string](), values: map[string]Value{}}
+}
+
+// Store the value under the key at the replica owning stamp, merging it with any value already there. The value should
+// be the current value updated locally. Returns the new map and the replica's advanced stamp. An anonymous stamp
+// cannot tag the key, so Put returns ErrAnonymous.
+func (m *Map) Put(stamp *itc.Stamp, key string, value Value) (*Map, *itc.Stamp, error) {
+    if stamp.Id.IsEmpty() {
+        return nil, nil, ErrAnonymous
+    }
+    if existing, ok := m.values[key]; ok {
+        merged, err := existing.mergeValue(value)
+        if err != nil {
+            return nil, nil, err
+        }
+        value = merged
+    }
+
+    keys, next := m.keys.Add(stamp, key)
+    r := m.copy()
+    r.keys = keys
+    r.values[key] = value
+
+    return r, next, nil
+}
+
+func (m *Map) Get(key string) (Value, bool) {
+    v, ok := m.values[key]
+    return v, ok
+}
+
+// Remove the key and its value
+func (m *Map) Remove(key string) *Map {
+    r := m.copy()
+    r.keys = m.keys.Remove(key)
+    delete(r.values, key)
+    return r
+}
+
+// The keys in sorted order
+func (m *Map) Keys() []string {
+    keys := m.keys.Elements()
+    sort.Strings(keys)
+    return keys
+}
+
+// Merge the state of another replica
+func (m *Map) Merge(other *Map) (*Map, error) {
+    r := &Map{keys: m.keys.Merge(other.keys), values: map[string]Value{}}
+
+    for _, key := range r.keys.Elements() {
+        mine, okm := m.values[key]
+        theirs, okt := other.values[key]
+        switch {
+        case okm && okt:
+            v, err := mine.mergeValue(theirs)
+            if err != nil {
+                return nil, err
+            }
+            r.values[key] = v
+        case okm:
+            r.values[key] = mine
+        case okt:
+            r.values[key] = theirs
+        }
+    }
+
+    return r, nil
+}
+
+// Look up a key and check the kind of its value
+func Lookup[V Value](m *Map, key string) (V, bool) {
+    v, ok := m.values[key].(V)
+    return v, ok
+}
+
+func (m *Map) copy() *Map {
+    r := &Map{keys: m.keys, values: make(map[string]Value, len(m.values)+1)}
+    for k, v := range m.values {
+        r.values[k] = v
+    }
+    return r
+}
+
+type mapWire struct {
+    Keys   []byte
+    Values map[string]Value
+}
+
+func (m *Map) Encode() ([]byte, error) {
+    keys, err := m.keys.Encode()
+    if err != nil {
+        return nil, err
+    }
+    return encodeGob(mapWire{Keys: keys, Values: m.values})
+}
+
+func DecodeMap(b []byte) (*Map, error) {
+    w := mapWire{}
+    if err := decodeGob(b, &w); err != nil {
+        return nil, err
+    }
+    keys, err := DecodeORSet[string](w.Keys)
+    if err != nil {
+        return nil, err
+    }
+    if w.Values == nil {
+        w.Values = map[string]Value{}
+    }
+    return &Map{keys: keys, values: w.Values}, nil
+}
+
+// Nesting
+
+func (r *MVRegister[T]) mergeValue(other Value) (Value, error) {
+    o, ok := other.(*MVRegister[T])
+    if !ok {
+        return nil, ErrTypeMismatch
+    }
+    return r.Merge(o), nil
+}
+
+func (r *LWWRegister[T]) mergeValue(other Value) (Value, error) {
+    o, ok := other.(*LWWRegister[T])
+    if !ok {
+        return nil, ErrTypeMismatch
+    }
+    return r.Merge(o), nil
+}
+
+func (s *ORSet[T]) mergeValue(other Value) (Value, error) {
+    o, ok := other.(*ORSet[T])
+    if !ok {
+        return nil, ErrTypeMismatch
+    }
+    return s.Merge(o), nil
+}
+
+func (c *PNCounter) mergeValue(other Value) (Value, error) {
+    o, ok := other.(*PNCounter)
+    if !ok {
+        return nil, ErrTypeMismatch
+    }
+    return c.Merge(o), nil
+}
+
+func (m *Map) mergeValue(other Value) (Value, error) {
+    o, ok := other.(*Map)
+    if !ok {
+        return nil, ErrTypeMismatch
+    }
+    return m.Merge(o)
+}
+
+// Gob support so that values can travel inside a Map
+
+func (r *MVRegister[T]) GobEncode() ([]byte, error) {
+    return r.Encode()
+}
+
+func (r *MVRegister[T]) GobDecode(b []byte) error {
+    d, err := DecodeMVRegister[T](b)
+    if err != nil {
+        return err
+    }
+    *r = *d
+    return nil
+}
+
+func (r *LWWRegister[T]) GobEncode() ([]byte, error) {
+    return r.Encode()
+}
+
+func (r *LWWRegister[T]) GobDecode(b []byte) error {
+    d, err := DecodeLWWRegister[T](b)
+    if err != nil {
+        return err
+    }
+    *r = *d
+    return nil
+}
+
+func (s *ORSet[T]) GobEncode() ([]byte, error) {
+    return s.Encode()
+}
+
+func (s *ORSet[T]) GobDecode(b []byte) error {
+    d, err := DecodeORSet[T](b)
+    if err != nil {
+        return err
+    }
+    *s = *d
+    return nil
+}
+
+func (c *PNCounter) GobEncode() ([]byte, error) {
+    return c.Encode()
+}
+
+func (c *PNCounter) GobDecode(b []byte) error {
+    d, err := DecodePNCounter(b)
+    if err != nil {
+        return err
+    }
+    *c = *d
+    return nil
+}
+
+func (m *Map) GobEncode() ([]byte, error) {
+    return m.Encode()
+}
+
+func (m *Map) GobDecode(b []byte) error {
+    d, err := DecodeMap(b)
+    if err != nil {
+        return err
+    }
+    *m = *d
+    return nil
+}
@@ -0,0 +1,265 @@
+package crdt
+
+import (
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/itc"
+    "sort"
+)
+
+// An observed-remove set with add-wins semantics. Every add tags the element with the dot of a fresh event and the set
+// keeps the history of all the events it has seen as an ITC event tree. A remove drops the tags the replica has
+// observed, so an add that is concurrent with a remove survives the merge.
+type ORSet[T comparable] struct {
+    entries map[T][]itc.Dot
+    context *itc.Event
+}
+
+func NewORSet[T comparable]() *ORSet[T] {
+    return &ORSet[T]{entries: map[T][]itc.Dot{}, context: itc.NewEvent(0)}
+}
+
+// Add the element at the replica owning stamp. Returns the new set and the replica's advanced stamp. An anonymous
+// stamp cannot tag the add, so the set and stamp come back unchanged.
+func (s *ORSet[T]) Add(stamp *itc.Stamp, elem T) (*ORSet[T], *itc.Stamp) {
+    next, dot := itc.NewStamp(stamp.Id, stamp.Event.Join(s.context)).AdvanceWithDot()
+    if dot.IsZero() {
+        return s, stamp
+    }
+
+    c := s.copy()
+    c.entries[elem] = []itc.Dot{dot}
+    c.context = next.Event
+
+    return c, next
+}
+
+// Remove the element. Only the adds this replica has seen are removed.
+func (s *ORSet[T]) Remove(elem T) *ORSet[T] {
+    if _, ok := s.entries[elem]; !ok {
+        return s
+    }
+
+    c := s.copy()
+    delete(c.entries, elem)
+
+    return c
+}
+
+func (s *ORSet[T]) Contains(elem T) bool {
+    _, ok := s.entries[elem]
+    return ok
+}
+
+// The elements in no particular order
+func (s *ORSet[T]) Elements() []T {
+    elems := make([]T, 0, len(s.entries))
+    for e := range s.entries {
+        elems = append(elems, e)
+    }
+    return elems
+}
+
+func (s *ORSet[T]) Len() int {
+    return len(s.entries)
+}
+
+// The dots tagging the element
+func (s *ORSet[T]) Dots(elem T) []itc.Dot {
+    dots := make([]itc.Dot, len(s.entries[elem]))
+    copy(dots, s.entries[elem])
+    return dots
+}
+
+// The history of every add the set has seen
+func (s *ORSet[T]) Context() *itc.Event {
+    return s.context
+}
+
+// Merge the state of another replica. A dot survives if both sides have it, or if one side has it and the other has
+// never seen it. A dot one side has seen but no longer holds was removed.
+func (s *ORSet[T]) Merge(other *ORSet[T]) *ORSet[T] {
+    merged := &ORSet[T]{
+        entries: map[T][]itc.Dot{},
+        context: s.context.Join(other.context),
+    }
+
+    for elem, dots := range s.entries {
+        theirs := other.entries[elem]
+        var kept []itc.Dot
+        for _, d := range dots {
+            if containsDot(theirs, d) || !other.context.ContainsDot(d) {
+                kept = append(kept, d)
+            }
+        }
+        for _, d := range theirs {
+            if !containsDot(dots, d) && !s.context.ContainsDot(d) {
+                kept = append(kept, d)
+            }
+        }
+        if len(kept) > 0 {
+            merged.entries[elem] = kept
+        }
+    }
+
+    for elem, theirs := range other.entries {
+        if _, ok := s.entries[elem]; ok {
+            continue
+        }
+        var kept []itc.Dot
+        for _, d := range theirs {
+            if !s.context.ContainsDot(d) {
+                kept = append(kept, d)
+            }
+        }
+        if len(kept) > 0 {
+            merged.entries[elem] = kept
+        }
+    }
+
+    return merged
+}
+
+// Compact the causal metadata. Duplicate dots are dropped and the dots of an element that are causally stable -
+// already seen by every replica, see itc.StabilityTracker - are reduced to one. Every replica keeps the same stable
+// dot, so compacted and uncompacted replicas still merge correctly.
+func (s *ORSet[T]) Compact(stable *itc.Event) *ORSet[T] {
+    c := &ORSet[T]{
+        entries: make(map[T][]itc.Dot, len(s.entries)),
+        context: s.context.Norm(),
+    }
+
+    for elem, dots := range s.entries {
+        var kept []itc.Dot
+        var first *itc.Dot
+        for i, d := range dots {
+            if containsDot(kept, d) || first != nil && first.Equal(d) {
+                continue
+            }
+            if stable != nil && stable.ContainsDot(d) {
+                if first == nil || dotLess(d, *first) {
+                    first = &dots[i]
+                }
+                continue
+            }
+            kept = append(kept, d)
+        }
+        if first != nil {
+            kept = append(kept, *first)
+        }
+        c.entries[elem] = kept
+    }
+
+    return c
+}
+
+func (s *ORSet[T]) copy() *ORSet[T] {
+    c := &ORSet[T]{
+        entries: make(map[T][]itc.Dot, len(s.entries)+1),
+        context: s.context,
+    }
+    for e, dots := range s.entries {
+        c.entries[e] = dots
+    }
+    return c
+}
+
+func containsDot(dots []itc.Dot, dot itc.Dot) bool {
+    for _, d := range dots {
+        if d.Equal(dot) {
+            return true
+        }
+    }
+    return false
+}
+
+// A total order on dots used to pick the same dot everywhere
+func dotLess(a itc.Dot, b itc.Dot) bool {
+    if a.Counter != b.Counter {
+        return a.Counter < b.Counter
+    }
+    return a.Interval.Print() < b.Interval.Print()
+}
+
+type dotWire struct {
+    Interval []byte
+    Counter  uint32
+}
+
+type orSetWire[T comparable] struct {
+    Elements []T
+    Dots     [][]dotWire
+    Context  []byte
+}
+
+// Encode the set. Equal sets encode to the same bytes: the dots of each element are sorted with dotLess and the
+// elements by their first dot, which no other element shares.
+func (s *ORSet[T]) Encode() ([]byte, error) {
+    type entry struct {
+        elem T
+        dots []itc.Dot
+    }
+    entries := make([]entry, 0, len(s.entries))
+    for elem, dots := range s.entries {
+        sorted := make([]itc.Dot, len(dots))
+        copy(sorted, dots)
+        sort.Slice(sorted, func(i, j int) bool { return dotLess(sorted[i], sorted[j]) })
+        entries = append(entries, entry{elem, sorted})
+    }
+    sort.Slice(entries, func(i, j int) bool { return dotLess(entries[i].dots[0], entries[j].dots[0]) })
+
+    w := orSetWire[T]{}
+    for _, e := range entries {
+        elem, dots := e.elem, e.dots
+        wire := make([]dotWire, len(dots))
+        for i, d := range dots {
+            b, err := proto.Marshal(d.Interval)
+            if err != nil {
+                return nil, err
+            }
+            wire[i] = dotWire{Interval: b, Counter: d.Counter}
+        }
+        w.Elements = append(w.Elements, elem)
+        w.Dots = append(w.Dots, wire)
+    }
+
+    context, err := marshalEvent(s.context)
+    if err != nil {
+        return nil, err
+    }
+    w.Context = context
+
+    return encodeGob(w)
+}
+
+func DecodeORSet[T comparable](b []byte) (*ORSet[T], error) {
+    w := orSetWire[T]{}
+    if err := decodeGob(b, &w); err != nil {
+        return nil, err
+    }
+    if len(w.Elements) != len(w.Dots) {
+        return nil, ErrMalformed
+    }
+
+    context, err := unmarshalEvent(w.Context)
+    if err != nil {
+        return nil, err
+    }
+    if context == nil {
+        return nil, ErrMalformed
+    }
+
+    s := &ORSet[T]{entries: make(map[T][]itc.Dot, len(w.Elements)), context: context}
+    for i, elem := range w.Elements {
+        dots := make([]itc.Dot, len(w.Dots[i]))
+        for j, d := range w.Dots[i] {
+            interval := &itc.Id{}
+            if err := proto.Unmarshal(d.Interval, interval); err != nil {
+                return nil, err
+            }
+            dots[j] = itc.Dot{Interval: interval, Counter: d.Counter}
+        }
+        s.entries[elem] = dots
+    }
+
+    return s, nil
+}
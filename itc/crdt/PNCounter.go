@@ -0,0 +1,130 @@
+package crdt
+
+import "github.com/ziglet.io/go-itc/itc"
+
+// A counter that can be incremented and decremented. Each replica records its contribution against an interval it
+// owns in its Id: the leftmost interval of the Id tree. Only the owner of an interval writes to it, so contributions
+// merge by taking the maximum per interval.
+//
+// Ids move between replicas through fork and join. A forked replica takes a copy of the counter along with its half of
+// the id. When an id joins another, on a join or a retirement, the counters move with it through Absorb: the joined
+// replica may carry on counting against an interval the other had been using, and starting from a stale view of that
+// interval would lose the other's increments on the next merge. An interval handed out from elsewhere, by a lease or
+// a recovery, needs the counter of its previous owner absorbed the same way before it is counted against.
+//
+// itc.Defragment moves intervals between two replicas without their counters. After defragmenting, each side must
+// Absorb the other's counter and old id, then count against its defragmented id rather than the joined id Absorb
+// returns.
+type PNCounter struct {
+    p map[string]uint64
+    n map[string]uint64
+}
+
+func NewPNCounter() *PNCounter {
+    return &PNCounter{p: map[string]uint64{}, n: map[string]uint64{}}
+}
+
+// Add n to the counter at the replica owning id. The id must not be empty.
+func (c *PNCounter) Increment(id *itc.Id, n uint64) *PNCounter {
+    r := c.copy()
+    r.p[intervalKey(id)] += n
+    return r
+}
+
+// Subtract n from the counter at the replica owning id. The id must not be empty.
+func (c *PNCounter) Decrement(id *itc.Id, n uint64) *PNCounter {
+    r := c.copy()
+    r.n[intervalKey(id)] += n
+    return r
+}
+
+// The current value: every increment minus every decrement
+func (c *PNCounter) Value() int64 {
+    var v int64
+    for _, n := range c.p {
+        v += int64(n)
+    }
+    for _, n := range c.n {
+        v -= int64(n)
+    }
+    return v
+}
+
+// Merge the state of another replica
+func (c *PNCounter) Merge(other *PNCounter) *PNCounter {
+    r := c.copy()
+    for k, n := range other.p {
+        if n > r.p[k] {
+            r.p[k] = n
+        }
+    }
+    for k, n := range other.n {
+        if n > r.n[k] {
+            r.n[k] = n
+        }
+    }
+    return r
+}
+
+// Take over the id of another replica along with its counter, as when it retires into this one or the two stamps are
+// joined. Returns the merged counter, which carries every count the other recorded against its intervals, and the
+// joined id to count against from now on. Fails with itc.ErrOverlappingIds if the ids overlap.
+func (c *PNCounter) Absorb(id *itc.Id, other *PNCounter, otherId *itc.Id) (*PNCounter, *itc.Id, error) {
+    if id.Overlaps(otherId) {
+        return nil, nil, itc.ErrOverlappingIds
+    }
+    return c.Merge(other), id.Sum(otherId), nil
+}
+
+func (c *PNCounter) copy() *PNCounter {
+    r := &PNCounter{p: make(map[string]uint64, len(c.p)+1), n: make(map[string]uint64, len(c.n)+1)}
+    for k, n := range c.p {
+        r.p[k] = n
+    }
+    for k, n := range c.n {
+        r.n[k] = n
+    }
+    return r
+}
+
+// The path to the leftmost 1 in the Id as a string of 0s (left) and 1s (right)
+func intervalKey(id *itc.Id) string {
+    var path []byte
+    for !id.IsLeaf {
+        if id.Left.IsEmpty() {
+            path = append(path, '1')
+            id = id.Right
+        } else {
+            path = append(path, '0')
+            id = id.Left
+        }
+    }
+    if id.Value == 0 {
+        panic("crdt: counter updated with an empty id")
+    }
+    return string(path)
+}
+
+type pnCounterWire struct {
+    P map[string]uint64
+    N map[string]uint64
+}
+
+func (c *PNCounter) Encode() ([]byte, error) {
+    return encodeGob(pnCounterWire{P: c.p, N: c.n})
+}
+
+func DecodePNCounter(b []byte) (*PNCounter, error) {
+    w := pnCounterWire{}
+    if err := decodeGob(b, &w); err != nil {
+        return nil, err
+    }
+    c := NewPNCounter()
+    for k, n := range w.P {
+        c.p[k] = n
+    }
+    for k, n := range w.N {
+        c.n[k] = n
+    }
+    return c, nil
+}
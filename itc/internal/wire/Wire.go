@@ -0,0 +1,70 @@
+// Package wire reads and writes the length-prefixed frames of the sync and coordinator protocols.
+//
+// Every frame on the wire is a 4 byte big-endian length followed by that many bytes. Within a body, variable length
+// fields are prefixed with their uvarint length.
+package wire
+
+import (
+    "encoding/binary"
+    "errors"
+    "io"
+)
+
+var (
+    // ErrFrameTooLarge is returned when a frame exceeds the size limit
+    ErrFrameTooLarge = errors.New("frame too large")
+    // ErrMalformed is returned for a field running past the end of a body
+    ErrMalformed = errors.New("malformed frame")
+)
+
+// Default limit on the size of a frame
+const DefaultMaxFrame = 1 << 20
+
+// The limit to apply, DefaultMaxFrame if max is not positive
+func FrameLimit(max int) int {
+    if max > 0 {
+        return max
+    }
+    return DefaultMaxFrame
+}
+
+// Write the body as one frame, returning the bytes written including the length
+func WriteFrame(w io.Writer, body []byte) (int, error) {
+    b := make([]byte, 4, 4+len(body))
+    binary.BigEndian.PutUint32(b, uint32(len(body)))
+    return w.Write(append(b, body...))
+}
+
+// Read the body of the next frame, refusing one over max bytes. Returns the bytes read including the length.
+func ReadFrame(r io.Reader, max int) ([]byte, int, error) {
+    var header [4]byte
+    if _, err := io.ReadFull(r, header[:]); err != nil {
+        return nil, 0, err
+    }
+    n := binary.BigEndian.Uint32(header[:])
+    if int64(n) > int64(max) {
+        return nil, 0, ErrFrameTooLarge
+    }
+
+    body := make([]byte, n)
+    if _, err := io.ReadFull(r, body); err != nil {
+        return nil, 0, err
+    }
+    return body, 4 + len(body), nil
+}
+
+// Append v prefixed with its length
+func AppendBytes(b []byte, v []byte) []byte {
+    var buf [binary.MaxVarintLen64]byte
+    n := binary.PutUvarint(buf[:], uint64(len(v)))
+    return append(append(b, buf[:n]...), v...)
+}
+
+// Read a field written by AppendBytes, returning it and the rest of the body
+func ReadBytes(b []byte) ([]byte, []byte, error) {
+    l, n := binary.Uvarint(b)
+    if n <= 0 || uint64(len(b)-n) < l {
+        return nil, nil, ErrMalformed
+    }
+    return b[n : n+int(l)], b[n+int(l):], nil
+}
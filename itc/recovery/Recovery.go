@@ -0,0 +1,127 @@
+// Package recovery reclaims the id of a replica that died without retiring.
+//
+// Every replica runs a Voter. To recover, Recover asks every known replica to promise a new epoch and report its Id.
+// Replicas that do not answer are suspected. Once a majority has promised, the region owned by no answering replica is
+// the complement of the sum of their Ids; a proposal granting that orphaned region to a survivor and fencing the
+// suspects is then accepted by a majority.
+//
+// Fencing is what keeps a falsely suspected replica from causing overlapping ids: it may still hold the Id that was
+// granted to the survivor, so voters refuse it through Admit and a fenced replica that learns of the new epoch drops
+// its Id. It must then rejoin as a new replica, keeping its event tree but acquiring a fresh Id.
+//
+// Until it learns of the fence, a suspected replica that is still alive keeps recording events in the orphaned region.
+// The survivor therefore holds the grant: a fenced replica that learns of the fence sends its event tree to the
+// survivor, which joins it and calls Acknowledge, and the region joins the survivor's Id once every fenced replica has
+// acknowledged. A replica that is really gone never does, so the survivor calls Expire once the fenced replicas'
+// leases have run out and none of them can still be recording.
+//
+// Recovery is only safe if the acceptors passed to Recover are every replica holding an Id, including replicas forked
+// since the last recovery. A voter whose Id changes between its promise and the proposal refuses to accept, so forks
+// and retirements racing with a recovery make it fail rather than grant an owned region.
+package recovery
+
+import (
+    "errors"
+    "github.com/ziglet.io/go-itc/itc"
+)
+
+var (
+    // ErrStaleEpoch is returned by a voter asked about an epoch older than one it has promised
+    ErrStaleEpoch = errors.New("recovery: stale epoch")
+    // ErrNoQuorum is returned when fewer than a majority of voters promise or accept
+    ErrNoQuorum = errors.New("recovery: no quorum")
+    // ErrFenced is returned for a replica fenced by a recovery
+    ErrFenced = errors.New("recovery: replica fenced")
+    // ErrIdChanged is returned by a voter whose Id changed after it promised
+    ErrIdChanged = errors.New("recovery: id changed since promise")
+    // ErrUnknownSurvivor is returned when the survivor did not promise
+    ErrUnknownSurvivor = errors.New("recovery: survivor did not promise")
+)
+
+// The answer of a voter to Prepare
+type Promise struct {
+    Replica string
+    Id      *itc.Id
+    // The epoch of the last proposal the voter accepted
+    Accepted uint64
+}
+
+// A recovery decided in an epoch
+type Proposal struct {
+    Epoch uint64
+    // The region owned by no live replica
+    Orphan *itc.Id
+    // The replica granted the orphaned region once the fenced replicas acknowledge
+    Survivor string
+    // The replicas that did not answer, which must never use their Ids again
+    Fenced []string
+}
+
+// A replica taking part in recovery
+type Acceptor interface {
+    Name() string
+    Prepare(epoch uint64) (Promise, error)
+    Accept(proposal Proposal) error
+}
+
+// Recover the Ids of the acceptors that do not answer in the epoch, granting them to the survivor. The epoch must be
+// higher than any used before, for example one more than the highest Voter.Epoch known. The acceptors must include
+// every replica holding an Id.
+func Recover(epoch uint64, acceptors []Acceptor, survivor string) (Proposal, error) {
+    quorum := len(acceptors)/2 + 1
+
+    promised := make([]Acceptor, 0, len(acceptors))
+    live := itc.NewId(0)
+    var grantee Acceptor
+    var fenced []string
+    for _, a := range acceptors {
+        p, err := a.Prepare(epoch)
+        if err == ErrStaleEpoch {
+            return Proposal{}, err
+        }
+        if err != nil {
+            fenced = append(fenced, a.Name())
+            continue
+        }
+
+        if p.Id.Overlaps(live) {
+            return Proposal{}, errors.New("recovery: live ids overlap")
+        }
+        live = live.Sum(p.Id)
+        if p.Replica == survivor {
+            grantee = a
+        } else {
+            promised = append(promised, a)
+        }
+    }
+    if len(promised)+1 < quorum {
+        return Proposal{}, ErrNoQuorum
+    }
+    if grantee == nil {
+        return Proposal{}, ErrUnknownSurvivor
+    }
+
+    proposal := Proposal{
+        Epoch:    epoch,
+        Orphan:   live.Complement(),
+        Survivor: survivor,
+        Fenced:   fenced,
+    }
+
+    // The survivor is granted the orphaned region only once the suspects are fenced by enough voters that, with the
+    // survivor, they form a majority
+    accepted := 0
+    for _, a := range promised {
+        if err := a.Accept(proposal); err == nil {
+            accepted++
+        }
+    }
+    if accepted+1 < quorum {
+        return proposal, ErrNoQuorum
+    }
+    if err := grantee.Accept(proposal); err != nil {
+        return proposal, err
+    }
+
+    return proposal, nil
+}
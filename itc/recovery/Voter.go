@@ -0,0 +1,211 @@
+package recovery
+
+import (
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/itc"
+    "sync"
+)
+
+// The recovery state of one replica: its Id, the epochs it has promised and accepted and the replicas fenced so far
+type Voter struct {
+    mu       sync.Mutex
+    name     string
+    id       *itc.Id
+    promised uint64
+    accepted uint64
+    // The Id reported in the outstanding promise
+    promisedId *itc.Id
+    fenced     map[string]uint64
+    // The orphaned region granted to the replica, held until the fenced replicas in waiting acknowledge
+    grant   *itc.Id
+    waiting map[string]bool
+}
+
+var _ Acceptor = (*Voter)(nil)
+
+func NewVoter(name string, id *itc.Id) *Voter {
+    return &Voter{
+        name:   name,
+        id:     id,
+        fenced:  make(map[string]uint64),
+        waiting: make(map[string]bool),
+    }
+}
+
+func (v *Voter) Name() string {
+    return v.name
+}
+
+// The replica's Id, empty once the replica is fenced
+func (v *Voter) Id() *itc.Id {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    return v.id
+}
+
+// Record a new Id for the replica after it forks or absorbs a retirement
+func (v *Voter) SetId(id *itc.Id) error {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if _, ok := v.fenced[v.name]; ok {
+        return ErrFenced
+    }
+    v.id = id
+    return nil
+}
+
+// The orphaned region granted to the replica and not yet summed into its Id, nil if none
+func (v *Voter) Pending() *itc.Id {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    return v.grant
+}
+
+// The epoch of the last accepted proposal
+func (v *Voter) Epoch() uint64 {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    return v.accepted
+}
+
+// True if the replica itself has been fenced and must rejoin with a fresh Id
+func (v *Voter) Fenced() bool {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    _, ok := v.fenced[v.name]
+    return ok
+}
+
+// Promise to accept nothing older than the epoch, reporting the replica's Id along with any region granted to it
+func (v *Voter) Prepare(epoch uint64) (Promise, error) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if epoch <= v.promised || epoch <= v.accepted {
+        return Promise{}, ErrStaleEpoch
+    }
+    if _, ok := v.fenced[v.name]; ok {
+        return Promise{}, ErrFenced
+    }
+    v.promised = epoch
+    v.promisedId = v.id
+
+    id := v.id
+    if v.grant != nil {
+        id = id.Sum(v.grant)
+    }
+    return Promise{Replica: v.name, Id: id, Accepted: v.accepted}, nil
+}
+
+// Accept the proposal of the epoch last promised: fence the suspects and, for the survivor, hold the orphaned region
+// until every fenced replica has acknowledged the fence, see Acknowledge
+func (v *Voter) Accept(proposal Proposal) error {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if proposal.Epoch != v.promised {
+        return ErrStaleEpoch
+    }
+    if !proto.Equal(v.id, v.promisedId) {
+        return ErrIdChanged
+    }
+    if proposal.Survivor == v.name && v.id.Overlaps(proposal.Orphan) {
+        return ErrIdChanged
+    }
+
+    v.learn(proposal)
+    if proposal.Survivor == v.name && !proposal.Orphan.IsEmpty() {
+        if v.grant == nil {
+            v.grant = itc.NewId(0)
+        }
+        v.grant = v.grant.Sum(proposal.Orphan)
+        for _, r := range proposal.Fenced {
+            v.waiting[r] = true
+        }
+        v.release()
+    }
+    return nil
+}
+
+// Record that a replica fenced in the epoch has learnt of it and dropped its Id. Its event tree must have been joined
+// into the survivor's first, so the survivor never records an event the fenced replica already did. The grant is summed
+// into the Id once no fenced replica is left to acknowledge.
+func (v *Voter) Acknowledge(replica string, epoch uint64) error {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if e, ok := v.fenced[replica]; !ok || e != epoch {
+        return ErrStaleEpoch
+    }
+    delete(v.waiting, replica)
+    v.release()
+    return nil
+}
+
+// Take the grant without waiting for the remaining acknowledgements. Only safe once the fenced replicas can no longer
+// be recording events, for example when their leases have run out; whatever they recorded and never sent is lost.
+func (v *Voter) Expire() {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    v.waiting = make(map[string]bool)
+    v.release()
+}
+
+// Sum the grant into the Id when nothing holds it
+func (v *Voter) release() {
+    if v.grant == nil || len(v.waiting) != 0 {
+        return
+    }
+    v.id = v.id.Sum(v.grant)
+    v.grant = nil
+}
+
+// Apply the fencing of a proposal accepted elsewhere, for a replica that missed it. A replica learning that it was
+// fenced drops its Id. The orphaned region is only ever granted through Accept.
+func (v *Voter) Learn(proposal Proposal) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if proposal.Epoch <= v.accepted {
+        return
+    }
+    v.learn(proposal)
+}
+
+func (v *Voter) learn(proposal Proposal) {
+    v.accepted = proposal.Epoch
+    if v.promised < proposal.Epoch {
+        v.promised = proposal.Epoch
+    }
+    for _, r := range proposal.Fenced {
+        // A replica stays fenced from the epoch it was first fenced in
+        if _, ok := v.fenced[r]; !ok {
+            v.fenced[r] = proposal.Epoch
+        }
+    }
+
+    if _, ok := v.fenced[v.name]; ok {
+        v.id = itc.NewId(0)
+    }
+}
+
+// Check a peer before syncing with it or absorbing its Id. Fenced replicas are refused, as are peers that have not
+// learnt the latest epoch this voter accepted and so may not know they are fenced.
+func (v *Voter) Admit(replica string, epoch uint64) error {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if _, ok := v.fenced[replica]; ok {
+        return ErrFenced
+    }
+    if epoch < v.accepted {
+        return ErrStaleEpoch
+    }
+    return nil
+}
@@ -0,0 +1,160 @@
+package sync
+
+import (
+    "errors"
+    "fmt"
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/itc"
+    "github.com/ziglet.io/go-itc/itc/internal/wire"
+    "io"
+)
+
+var (
+    // ErrFrameTooLarge is returned when a frame exceeds the size limit
+    ErrFrameTooLarge = wire.ErrFrameTooLarge
+    // ErrMalformed is returned for a frame that does not decode
+    ErrMalformed = errors.New("sync: malformed frame")
+    // ErrOverlap is returned when a fork is requested by a peer whose Id overlaps the local one
+    ErrOverlap = errors.New("sync: ids overlap")
+    // ErrNoToken is returned when a fork or defragmentation is started without a token
+    ErrNoToken = errors.New("sync: id exchange without a token")
+    // ErrInDoubt wraps the error of an Id exchange that failed after its commit was sent
+    ErrInDoubt = errors.New("sync: id exchange in doubt")
+    // ErrTokenReused is returned when an Id exchange reuses the token of one recorded for a different initiator Id
+    ErrTokenReused = errors.New("sync: token recorded for another id")
+)
+
+// The frames of a session, in the order they are sent, then those of a retirement
+const (
+    kindHello byte = iota + 1
+    kindReply
+    kindCommit
+    kindCommitted
+    kindRetire
+    kindRetired
+)
+
+// The exchange of Ids asked for in the flags of a hello
+const (
+    flagFork byte = iota + 1
+    flagDefragment
+)
+
+// The flags of a reply refusing the Id exchange, in place of the responder's clock.Ordering: refused for overlapping
+// Ids and reused for a token recorded for another Id
+const (
+    refused byte = 0xff
+    reused  byte = 0xfe
+)
+
+// Default limit on the size of a frame
+const DefaultMaxFrame = wire.DefaultMaxFrame
+
+// The body of every frame, sent with wire.WriteFrame:
+//
+//  kind     1 byte
+//  flags    1 byte, for a hello the Id exchange and for a reply the responder's clock.Ordering, refused or reused
+//  event    uvarint length and a protobuf Event, empty when absent
+//  id       uvarint length and a protobuf Id, empty when absent
+//  token    uvarint length and the bytes of an exchange or retirement token, empty when absent
+type frame struct {
+    kind  byte
+    flags byte
+    event *itc.Event
+    id    *itc.Id
+    token string
+}
+
+func writeFrame(w io.Writer, f frame) (int, error) {
+    body := []byte{f.kind, f.flags}
+
+    var err error
+    if body, err = appendMessage(body, f.event); err != nil {
+        return 0, err
+    }
+    if body, err = appendMessage(body, f.id); err != nil {
+        return 0, err
+    }
+    body = wire.AppendBytes(body, []byte(f.token))
+
+    return wire.WriteFrame(w, body)
+}
+
+func readFrame(r io.Reader, kind byte, maxFrame int) (frame, int, error) {
+    body, n, err := wire.ReadFrame(r, maxFrame)
+    if err != nil {
+        return frame{}, 0, err
+    }
+    if len(body) < 2 {
+        return frame{}, 0, ErrMalformed
+    }
+
+    f := frame{kind: body[0], flags: body[1]}
+    if f.kind != kind {
+        return frame{}, 0, fmt.Errorf("sync: expected frame %d, got %d", kind, f.kind)
+    }
+
+    rest := body[2:]
+    event, rest, err := readMessage(rest, func() proto.Message { return &itc.Event{} })
+    if err != nil {
+        return frame{}, 0, err
+    }
+    id, rest, err := readMessage(rest, func() proto.Message { return &itc.Id{} })
+    if err != nil {
+        return frame{}, 0, err
+    }
+    token, rest, err := wire.ReadBytes(rest)
+    if err != nil || len(rest) != 0 {
+        return frame{}, 0, ErrMalformed
+    }
+    if event != nil {
+        f.event = event.(*itc.Event)
+    }
+    if id != nil {
+        f.id = id.(*itc.Id)
+    }
+    f.token = string(token)
+
+    return f, n, nil
+}
+
+func appendMessage(b []byte, m proto.Message) ([]byte, error) {
+    var encoded []byte
+    if m != nil && !isNil(m) {
+        var err error
+        if encoded, err = proto.Marshal(m); err != nil {
+            return nil, err
+        }
+        // An empty encoding is a valid message, mark it so it is not read back as absent
+        encoded = append([]byte{1}, encoded...)
+    }
+
+    return wire.AppendBytes(b, encoded), nil
+}
+
+func readMessage(b []byte, create func() proto.Message) (proto.Message, []byte, error) {
+    encoded, rest, err := wire.ReadBytes(b)
+    if err != nil {
+        return nil, nil, ErrMalformed
+    }
+    if len(encoded) == 0 {
+        return nil, rest, nil
+    }
+
+    m := create()
+    if err := proto.Unmarshal(encoded[1:], m); err != nil {
+        return nil, nil, ErrMalformed
+    }
+
+    return m, rest, nil
+}
+
+func isNil(m proto.Message) bool {
+    switch v := m.(type) {
+    case *itc.Event:
+        return v == nil
+    case *itc.Id:
+        return v == nil
+    }
+    return false
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "github.com/ziglet.io/go-itc/itc"
+    "io"
+)
+
+// A replica leaving for good hands its Id and final event to a donor, which joins them into its own stamp so the share
+// of the id space is not lost.
+//
+// The leaving replica saves the Retirement before sending it and, after a crash or a failed attempt, resends the same
+// Retirement until one is acknowledged. Only then may it discard its state. The donor records the token of every
+// retirement it absorbs, so a resent retirement is acknowledged again without summing the id twice, even if the donor
+// has since forked the absorbed share away.
+type Retirement struct {
+    Token string
+    Stamp *itc.Stamp
+}
+
+// Tokens of the retirements a donor has absorbed. A token can be forgotten once its replica can no longer retry.
+type Absorbed map[string]bool
+
+// Prepare the retirement of the stamp under a fresh random token
+func NewRetirement(stamp *itc.Stamp) (Retirement, error) {
+    token, err := NewToken()
+    if err != nil {
+        return Retirement{}, err
+    }
+    return Retirement{Token: token, Stamp: stamp}, nil
+}
+
+// A fresh random token for a retirement or an Id exchange
+func NewToken() (string, error) {
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(b), nil
+}
+
+// Join the retirement into the donor's stamp. A retirement already absorbed only joins its event again. Returns
+// ErrOverlap if a new retirement's Id overlaps the donor's.
+func Absorb(stamp *itc.Stamp, absorbed Absorbed, r Retirement) (*itc.Stamp, Absorbed, error) {
+    if absorbed[r.Token] {
+        return itc.NewStamp(stamp.Id, stamp.Event.Join(r.Stamp.Event)), absorbed, nil
+    }
+    if stamp.Id.Overlaps(r.Stamp.Id) {
+        return stamp, absorbed, ErrOverlap
+    }
+
+    next := make(Absorbed, len(absorbed)+1)
+    for t := range absorbed {
+        next[t] = true
+    }
+    next[r.Token] = true
+
+    return stamp.Join(r.Stamp), next, nil
+}
+
+// Send the retirement to a donor running AcceptRetirement and wait for it to be acknowledged
+func Retire(rw io.ReadWriter, r Retirement, options Options) error {
+    _, err := writeFrame(rw, frame{kind: kindRetire, event: r.Stamp.Event, id: r.Stamp.Id, token: r.Token})
+    if err != nil {
+        return err
+    }
+
+    reply, _, err := readFrame(rw, kindRetired, frameLimit(options))
+    if err != nil {
+        return err
+    }
+    if reply.flags != 0 {
+        return ErrOverlap
+    }
+
+    return nil
+}
+
+// Absorb a retirement sent by Retire. The new stamp and tokens are passed to commit, which should persist them, before
+// the leaving replica is acknowledged; commit may be nil. A retirement that overlaps the donor's Id is refused and
+// ErrOverlap returned on both sides. If only the acknowledgement fails, the absorbed state is returned along with the
+// error and must be kept.
+func AcceptRetirement(rw io.ReadWriter, stamp *itc.Stamp, absorbed Absorbed, commit func(*itc.Stamp, Absorbed) error,
+    options Options) (*itc.Stamp, Absorbed, error) {
+
+    f, _, err := readFrame(rw, kindRetire, frameLimit(options))
+    if err != nil {
+        return stamp, absorbed, err
+    }
+    if f.event == nil || f.id == nil || f.token == "" {
+        return stamp, absorbed, ErrMalformed
+    }
+
+    next, nextAbsorbed, err := Absorb(stamp, absorbed, Retirement{Token: f.token, Stamp: itc.NewStamp(f.id, f.event)})
+    if err == ErrOverlap {
+        writeFrame(rw, frame{kind: kindRetired, flags: 1})
+        return stamp, absorbed, err
+    }
+    if commit != nil {
+        if err := commit(next, nextAbsorbed); err != nil {
+            return stamp, absorbed, err
+        }
+    }
+
+    if _, err := writeFrame(rw, frame{kind: kindRetired}); err != nil {
+        // The retirement is absorbed and committed, the leaving replica will retry and be acknowledged then
+        return next, nextAbsorbed, err
+    }
+
+    return next, nextAbsorbed, nil
+}
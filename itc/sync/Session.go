@@ -0,0 +1,238 @@
+// Package sync is a transport-independent anti-entropy protocol for two replicas holding ITC stamps.
+//
+// The initiator sends its event tree and the responder replies with how the two compare and the delta of its own tree
+// over the initiator's, so that only the parts the initiator is missing travel back. Optionally the two also run the
+// paper's sync operation: the responder sums both Ids and splits the result, handing one half back to the initiator,
+// who commits the exchange. Defragmenting instead reassigns the two Ids the same way through itc.Defragment. A replica
+// leaving for good hands its Id to a donor with Retire.
+//
+// An Id exchange is keyed by a token. The responder takes its new Id on the commit and records the token, and the
+// initiator takes its own only once the commit is acknowledged. An initiator that loses the acknowledgement gets
+// ErrInDoubt: it must not use its stamp until a session with the same token and Id succeeds, which hands it the Id
+// recorded by the responder.
+//
+// Sessions run over any io.ReadWriter with length-prefixed frames and never write while the peer is writing, so
+// synchronous transports such as net.Pipe work.
+package sync
+
+import (
+    "fmt"
+    "github.com/gogo/protobuf/proto"
+    "github.com/ziglet.io/go-itc/clock"
+    "github.com/ziglet.io/go-itc/itc"
+    "github.com/ziglet.io/go-itc/itc/internal/wire"
+    "io"
+)
+
+type Options struct {
+    // Also join the two Ids and fork them again, evening out the shares of the id space
+    Fork bool
+    // Also reassign the two Ids with itc.Defragment, keeping the shares but making them contiguous, committed under the
+    // Token as a fork is. Ignored with Fork.
+    Defragment bool
+    // Identifies a fork or defragmentation, required with either. The initiator saves it before the session and reuses
+    // it for every retry of the exchange.
+    Token string
+    // Largest frame accepted, DefaultMaxFrame if 0
+    MaxFrame int
+}
+
+// The Id exchanges a responder committed, by their token. A token can be forgotten once its initiator can no longer
+// retry.
+type Exchanged map[string]Exchange
+
+// A committed Id exchange
+type Exchange struct {
+    // The Id the initiator held, which a retry must present again
+    Initiator *itc.Id
+    // The new Id handed to the initiator
+    Handed *itc.Id
+}
+
+// The outcome of a session for one side
+type Result struct {
+    // The stamp to keep: the local Id, or the new share after a fork or defragmentation, with the joined event tree
+    Stamp *itc.Stamp
+    // How the local stamp compared with the peer's before the session
+    Ordering clock.Ordering
+    // Bytes written and read, including framing
+    Sent     int
+    Received int
+}
+
+// Start a session with a peer running Respond
+func Initiate(rw io.ReadWriter, stamp *itc.Stamp, options Options) (Result, error) {
+    result := Result{}
+    maxFrame := frameLimit(options)
+
+    hello := frame{kind: kindHello, event: stamp.Event}
+    switch {
+    case options.Fork:
+        hello.flags = flagFork
+    case options.Defragment:
+        hello.flags = flagDefragment
+    }
+    exchange := hello.flags != 0
+    if exchange {
+        if options.Token == "" {
+            return result, ErrNoToken
+        }
+        hello.id = stamp.Id
+        hello.token = options.Token
+    }
+    n, err := writeFrame(rw, hello)
+    result.Sent += n
+    if err != nil {
+        return result, err
+    }
+
+    reply, n, err := readFrame(rw, kindReply, maxFrame)
+    result.Received += n
+    if err != nil {
+        return result, err
+    }
+    switch reply.flags {
+    case refused:
+        return result, ErrOverlap
+    case reused:
+        return result, ErrTokenReused
+    }
+    if reply.event == nil || exchange && reply.id == nil {
+        return result, ErrMalformed
+    }
+
+    result.Ordering = flip(clock.Ordering(reply.flags))
+    id := stamp.Id
+    if exchange {
+        // From here on the responder may have taken its new Id
+        n, err = writeFrame(rw, frame{kind: kindCommit, token: options.Token})
+        result.Sent += n
+        if err != nil {
+            return result, fmt.Errorf("%w: %v", ErrInDoubt, err)
+        }
+        _, n, err = readFrame(rw, kindCommitted, maxFrame)
+        result.Received += n
+        if err != nil {
+            return result, fmt.Errorf("%w: %v", ErrInDoubt, err)
+        }
+        id = reply.id
+    }
+    result.Stamp = itc.NewStamp(id, stamp.Event.ApplyDelta(reply.event))
+
+    return result, nil
+}
+
+// Answer a session started by Initiate. A fork or defragmentation with a peer whose Id overlaps the local one is
+// refused and ErrOverlap returned on both sides. Otherwise the new stamp and the exchanged tokens are passed to commit,
+// which should persist them, before the commit is acknowledged; commit may be nil. An exchange whose token was already
+// recorded hands the initiator its recorded Id again and leaves the local Id as it is, provided the initiator presents
+// the Id it held then; otherwise the exchange is refused with ErrTokenReused. If only the acknowledgement fails, the
+// committed result is returned along with the error and must be kept.
+func Respond(rw io.ReadWriter, stamp *itc.Stamp, exchanged Exchanged, commit func(*itc.Stamp, Exchanged) error,
+    options Options) (Result, Exchanged, error) {
+
+    result := Result{}
+    maxFrame := frameLimit(options)
+
+    hello, n, err := readFrame(rw, kindHello, maxFrame)
+    result.Received += n
+    if err != nil {
+        return result, exchanged, err
+    }
+    exchange := hello.flags != 0
+    if hello.event == nil || exchange && (hello.id == nil || hello.token == "") || hello.flags > flagDefragment {
+        return result, exchanged, ErrMalformed
+    }
+
+    peer := itc.NewStamp(itc.NewId(0), hello.event)
+    ordering, err := stamp.Compare(peer)
+    if err != nil {
+        return result, exchanged, err
+    }
+    result.Ordering = ordering
+
+    reply := frame{kind: kindReply, flags: byte(ordering), event: stamp.Event.Delta(hello.event)}
+    event := stamp.Event.Join(hello.event)
+    id := stamp.Id
+    if exchange {
+        recorded, retried := exchanged[hello.token]
+        switch {
+        case retried && !proto.Equal(recorded.Initiator, hello.id):
+            n, _ = writeFrame(rw, frame{kind: kindReply, flags: reused})
+            result.Sent += n
+            return result, exchanged, ErrTokenReused
+        case retried:
+            reply.id = recorded.Handed
+        case stamp.Id.Overlaps(hello.id):
+            n, _ = writeFrame(rw, frame{kind: kindReply, flags: refused})
+            result.Sent += n
+            return result, exchanged, ErrOverlap
+        case hello.flags == flagFork:
+            reply.id, id = stamp.Id.Sum(hello.id).Split()
+        default:
+            theirs, ours, err := itc.Defragment(itc.NewStamp(hello.id, hello.event), stamp)
+            if err != nil {
+                return result, exchanged, err
+            }
+            reply.id, id = theirs.Id, ours.Id
+        }
+    }
+
+    n, err = writeFrame(rw, reply)
+    result.Sent += n
+    if err != nil {
+        return result, exchanged, err
+    }
+
+    if !exchange {
+        result.Stamp = itc.NewStamp(id, event)
+        return result, exchanged, nil
+    }
+
+    f, n, err := readFrame(rw, kindCommit, maxFrame)
+    result.Received += n
+    if err != nil {
+        return result, exchanged, err
+    }
+    if f.token != hello.token {
+        return result, exchanged, ErrMalformed
+    }
+
+    next := make(Exchanged, len(exchanged)+1)
+    for t, i := range exchanged {
+        next[t] = i
+    }
+    next[hello.token] = Exchange{Initiator: hello.id, Handed: reply.id}
+
+    committed := itc.NewStamp(id, event)
+    if commit != nil {
+        if err := commit(committed, next); err != nil {
+            return result, exchanged, err
+        }
+    }
+    result.Stamp = committed
+
+    n, err = writeFrame(rw, frame{kind: kindCommitted})
+    result.Sent += n
+    if err != nil {
+        // The exchange is committed, the initiator will retry and be handed the same Id
+        return result, next, err
+    }
+
+    return result, next, nil
+}
+
+func frameLimit(options Options) int {
+    return wire.FrameLimit(options.MaxFrame)
+}
+
+// The ordering seen from the other side
+func flip(o clock.Ordering) clock.Ordering {
+    switch o {
+    case clock.Before:
+        return clock.After
+    case clock.After:
+        return clock.Before
+    }
+    return o
+}
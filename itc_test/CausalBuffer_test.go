@@ -0,0 +1,210 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"testing"
+	"time"
+)
+
+func TestStampPeekSend(t *testing.T) {
+	s := itc.SeedStamp()
+	anonymous, kept := s.Peek()
+	assert.True(proto.Equal(anonymous.Id, itc.NewId(0)), t)
+	assert.True(proto.Equal(anonymous.Event, s.Event), t)
+	assert.True(proto.Equal(kept, s), t)
+
+	message, kept := s.Send()
+	assert.True(proto.Equal(message.Event, kept.Event), t)
+	assert.False(kept.Leq(s), t)
+
+	a, b := s.Fork()
+	b = b.Receive(message)
+	assert.True(message.Leq(b), t)
+	assert.False(b.Leq(a), t)
+}
+
+func receive(b *itc.CausalBuffer[string], t *testing.T) string {
+	select {
+	case m := <-b.Deliveries():
+		return m.Payload
+	case <-time.After(time.Second):
+		t.Fatal("no delivery")
+	}
+	return ""
+}
+
+func TestCausalBufferOrder(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	m1, a := itc.NewMessage(a, "m1")
+	m2, a := itc.NewMessage(a, "m2")
+
+	// b replies after seeing m1
+	b = itc.Receive(b, m1)
+	m3, b := itc.NewMessage(b, "m3")
+
+	c := itc.NewCausalBuffer[string](itc.NewEvent(0), itc.BufferOptions{})
+	defer c.Close()
+
+	assert.Nil(c.Push(m3), t)
+	assert.Nil(c.Push(m2), t)
+	assert.True(c.Buffered() == 2, t)
+	assert.Nil(c.Push(m1), t)
+
+	assert.True(receive(c, t) == "m1", t)
+	second := receive(c, t)
+	third := receive(c, t)
+	assert.True(second == "m2" && third == "m3" || second == "m3" && third == "m2", t)
+	assert.True(c.Buffered() == 0, t)
+
+	// Duplicates are dropped
+	assert.Nil(c.Push(m2), t)
+	assert.True(c.Buffered() == 0, t)
+	assert.True(m2.Stamp.Event.Leq(c.Delivered()), t)
+	assert.True(m3.Stamp.Event.Leq(c.Delivered()), t)
+}
+
+func TestCausalBufferObserve(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	m1, a := itc.NewMessage(a, "m1")
+	b = itc.Receive(b, m1)
+	m2, _ := itc.NewMessage(b, "m2")
+
+	// a's buffer is waiting for its own message
+	c := itc.NewCausalBuffer[string](itc.NewEvent(0), itc.BufferOptions{})
+	defer c.Close()
+	assert.Nil(c.Push(m2), t)
+	assert.True(c.Buffered() == 1, t)
+
+	c.Observe(a.Event)
+	assert.True(receive(c, t) == "m2", t)
+}
+
+func TestCausalBufferReceive(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	m1, _ := itc.NewMessage(a, "m1")
+
+	c := itc.NewCausalBuffer[string](itc.NewEvent(0), itc.BufferOptions{})
+	defer c.Close()
+	assert.Nil(c.Push(m1), t)
+	assert.True(receive(c, t) == "m1", t)
+
+	// A reply after Stamp.Receive depends on b's receive event, which is never sent
+	stuck, _ := itc.NewMessage(b.Receive(m1.Stamp), "stuck")
+	assert.Nil(c.Push(stuck), t)
+	assert.True(c.Buffered() == 1, t)
+
+	reply, _ := itc.NewMessage(itc.Receive(b, m1), "reply")
+	assert.Nil(c.Push(reply), t)
+	assert.True(receive(c, t) == "reply", t)
+	assert.True(proto.Equal(reply.Deps.Event, m1.Stamp.Event), t)
+}
+
+func TestCausalBufferLimit(t *testing.T) {
+	a, _ := itc.SeedStamp().Fork()
+	m1, a := itc.NewMessage(a, "m1")
+	m2, a := itc.NewMessage(a, "m2")
+	m3, _ := itc.NewMessage(a, "m3")
+
+	c := itc.NewCausalBuffer[string](itc.NewEvent(0), itc.BufferOptions{MaxBuffered: 1})
+	defer c.Close()
+	assert.Nil(c.Push(m2), t)
+	assert.True(c.Push(m3) == itc.ErrBufferFull, t)
+
+	// A deliverable message is always accepted
+	assert.Nil(c.Push(m1), t)
+	assert.True(receive(c, t) == "m1", t)
+	assert.True(receive(c, t) == "m2", t)
+
+	c.Close()
+	assert.True(c.Push(m3) == itc.ErrBufferClosed, t)
+	_, ok := <-c.Deliveries()
+	assert.False(ok, t)
+}
+
+func TestCausalBufferGap(t *testing.T) {
+	a, _ := itc.SeedStamp().Fork()
+	m1, a := itc.NewMessage(a, "m1")
+	m2, _ := itc.NewMessage(a, "m2")
+
+	c := itc.NewCausalBuffer[string](itc.NewEvent(0), itc.BufferOptions{Timeout: 20 * time.Millisecond})
+	defer c.Close()
+	assert.Nil(c.Push(m2), t)
+
+	select {
+	case gap := <-c.Gaps():
+		assert.True(gap.Message.Payload == "m2", t)
+		assert.True(gap.Waiting >= 20*time.Millisecond, t)
+		assert.True(len(gap.Missing) == 1, t)
+		assert.True(gap.Missing[0].From == 1 && gap.Missing[0].To == 1, t)
+	case <-time.After(time.Second):
+		t.Fatal("no gap reported")
+	}
+
+	// The gap can still resolve
+	assert.Nil(c.Push(m1), t)
+	assert.True(receive(c, t) == "m1", t)
+	assert.True(receive(c, t) == "m2", t)
+}
+
+func TestCausalBufferShortTimeout(t *testing.T) {
+	a, _ := itc.SeedStamp().Fork()
+	_, a = itc.NewMessage(a, "m1")
+	m2, _ := itc.NewMessage(a, "m2")
+
+	// Shorter than the check interval can be divided into
+	c := itc.NewCausalBuffer[string](itc.NewEvent(0), itc.BufferOptions{Timeout: 3 * time.Nanosecond})
+	defer c.Close()
+	assert.Nil(c.Push(m2), t)
+
+	select {
+	case gap := <-c.Gaps():
+		assert.True(gap.Message.Payload == "m2", t)
+	case <-time.After(time.Second):
+		t.Fatal("no gap reported")
+	}
+}
+
+func TestCausalBufferBackpressure(t *testing.T) {
+	a, _ := itc.SeedStamp().Fork()
+	var messages []itc.Message[string]
+	for _, p := range []string{"m1", "m2", "m3", "m4"} {
+		var m itc.Message[string]
+		m, a = itc.NewMessage(a, p)
+		messages = append(messages, m)
+	}
+
+	c := itc.NewCausalBuffer[string](itc.NewEvent(0), itc.BufferOptions{MaxReady: 1})
+	defer c.Close()
+
+	// m2 arrives first and waits, m1 releases itself and fills the ready queue, holding m2 back
+	assert.Nil(c.Push(messages[1]), t)
+	assert.Nil(c.Push(messages[0]), t)
+	assert.True(c.Buffered() == 1, t)
+
+	pushed := make(chan error)
+	go func() {
+		pushed <- c.Push(messages[2])
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("push did not block on a full ready queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Receiving m1 lets m2 take its place, and only then is there room for m3
+	assert.True(receive(c, t) == "m1", t)
+	assert.True(receive(c, t) == "m2", t)
+	assert.Nil(<-pushed, t)
+	assert.True(receive(c, t) == "m3", t)
+
+	// Closing wakes a blocked push
+	assert.Nil(c.Push(messages[3]), t)
+	go func() {
+		pushed <- c.Push(messages[3])
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	assert.True(<-pushed == itc.ErrBufferClosed, t)
+}
@@ -0,0 +1,114 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/clock"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func TestCompareEvents(t *testing.T) {
+	rng := rand.New(rand.NewSource(50))
+
+	for k := 0; k < 1000; k++ {
+		a, b := randomEvent(rng, 6), randomEvent(rng, 6)
+		if k%4 == 0 {
+			b = a.Join(b)
+		}
+
+		assert.True(itc.LeqEvents(a, b) == a.Leq(b), t, a.Print(), b.Print())
+		assert.True(itc.CompareEvents(a, b) == clock.FromLeq(a.Leq(b), b.Leq(a)), t, a.Print(), b.Print())
+		assert.True(itc.EqualEvents(a, b) == proto.Equal(a, b), t, a.Print(), b.Print())
+
+		c := proto.Clone(a).(*itc.Event)
+		assert.True(itc.EqualEvents(a, c) && itc.CompareEvents(a, c) == clock.Equal, t)
+	}
+}
+
+func TestCompareStamps(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	a = a.Advance()
+
+	o, err := a.Compare(b)
+	assert.Nil(err, t)
+	assert.True(o == clock.After, t)
+
+	b = b.Advance()
+	o, _ = a.Compare(b)
+	assert.True(o == clock.Concurrent, t)
+
+	o, _ = a.Compare(a.Join(b))
+	assert.True(o == clock.Before, t)
+}
+
+func TestCompareAllocs(t *testing.T) {
+	stamps := benchmarkStamps()
+	events := make([]*itc.Event, len(stamps))
+	for i, s := range stamps {
+		events[i] = s.Event
+	}
+	top := itc.JoinEvents(events...)
+	_, deepEvent := deep(2000)
+	deeper := itc.NewStamp(deep(2000)).Advance().Event
+
+	for _, e := range events {
+		allocs := testing.AllocsPerRun(10, func() {
+			itc.LeqEvents(e, top)
+			itc.CompareEvents(e, top)
+			itc.EqualEvents(e, top)
+		})
+		assert.True(allocs == 0, t)
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		itc.LeqEvents(deepEvent, deeper)
+		itc.CompareEvents(deepEvent, deeper)
+	})
+	assert.True(allocs == 0, t)
+	assert.True(itc.CompareEvents(deepEvent, deeper) == clock.Before, t)
+}
+
+func BenchmarkCompare(b *testing.B) {
+	stamps := benchmarkStamps()
+	events := make([]*itc.Event, len(stamps))
+	for i, s := range stamps {
+		events[i] = s.Event
+	}
+	top := itc.JoinEvents(events...)
+	n := len(events)
+
+	cases := []struct {
+		name string
+		run  func(i int)
+	}{
+		{"Leq/recursive", func(i int) { events[i%n].Leq(top) }},
+		{"Leq/offsets", func(i int) { itc.LeqEvents(events[i%n], top) }},
+		{"Compare/recursive", func(i int) { clock.FromLeq(events[i%n].Leq(events[(i+1)%n]), events[(i+1)%n].Leq(events[i%n])) }},
+		{"Compare/offsets", func(i int) { itc.CompareEvents(events[i%n], events[(i+1)%n]) }},
+		{"Equal/proto", func(i int) { proto.Equal(events[i%n], top) }},
+		{"Equal/offsets", func(i int) { itc.EqualEvents(events[i%n], top) }},
+	}
+
+	for _, c := range cases {
+		c := c
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.run(i)
+			}
+		})
+	}
+
+	// The fast paths must stay allocation free
+	for _, run := range []func(){
+		func() { itc.LeqEvents(events[0], top) },
+		func() { itc.CompareEvents(events[0], events[1]) },
+		func() { itc.EqualEvents(events[0], top) },
+	} {
+		if allocs := testing.AllocsPerRun(100, run); allocs != 0 {
+			b.Fatalf("%v allocations per comparison", allocs)
+		}
+	}
+}
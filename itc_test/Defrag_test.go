@@ -0,0 +1,100 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func id(l, r *itc.Id) *itc.Id {
+	return &itc.Id{IsLeaf: false, Left: l, Right: r}
+}
+
+func TestIdFragments(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+
+	assert.True(zero.Fragments() == 0, t)
+	assert.True(one.Fragments() == 1, t)
+	assert.True(id(one, id(zero, one)).Fragments() == 2, t)
+	// Contiguous across subtrees
+	assert.True(id(id(zero, one), id(one, zero)).Fragments() == 1, t)
+	assert.True(id(id(one, zero), id(one, zero)).Fragments() == 2, t)
+	assert.True(id(id(one, zero), id(one, zero)).Depth() == 2, t)
+}
+
+func TestDefragment(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+
+	// a owns three quarters in two pieces with b's quarter in between
+	a := itc.NewStamp(id(one, id(zero, one)), itc.NewEvent(0)).Advance()
+	b := itc.NewStamp(id(zero, id(one, zero)), itc.NewEvent(0)).Advance()
+
+	a2, b2, err := itc.Defragment(a, b)
+	assert.Nil(err, t)
+	assert.True(proto.Equal(a2.Id, id(one, id(one, zero))), t, a2.Id.Print())
+	assert.True(proto.Equal(b2.Id, id(zero, id(zero, one))), t, b2.Id.Print())
+	assert.True(a2.Id.Fragments() == 1 && b2.Id.Fragments() == 1, t)
+
+	joined := a.Event.Join(b.Event)
+	assert.True(proto.Equal(a2.Event, joined), t)
+	assert.True(proto.Equal(b2.Event, joined), t)
+
+	// Contiguous ids are left alone
+	c, d := itc.SeedStamp().Fork()
+	c2, d2, err := itc.Defragment(c, d)
+	assert.Nil(err, t)
+	assert.True(proto.Equal(c2.Id, c.Id), t)
+	assert.True(proto.Equal(d2.Id, d.Id), t)
+
+	c2, d2, err = itc.Defragment(c, c)
+	assert.True(err == itc.ErrOverlappingIds, t)
+	assert.True(c2 == nil && d2 == nil, t)
+}
+
+func TestDefragmentRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(43))
+	stamps := randomStamps(rng, 8)
+
+	// Hand half of one id to another at random to scatter the ids
+	for k := 0; k < 40; k++ {
+		i, j := rng.Intn(len(stamps)), rng.Intn(len(stamps))
+		if i == j {
+			continue
+		}
+		kept, given := stamps[j].Advance().Fork()
+		stamps[i], stamps[j] = stamps[i].Join(given), kept
+	}
+
+	before := 0
+	for _, s := range stamps {
+		before += s.Id.Fragments()
+	}
+
+	for k := 0; k < 200; k++ {
+		i, j := rng.Intn(len(stamps)), rng.Intn(len(stamps))
+		if i == j {
+			continue
+		}
+		a, b := stamps[i], stamps[j]
+		a2, b2, err := itc.Defragment(a, b)
+		assert.Nil(err, t)
+
+		assert.False(a2.Id.Overlaps(b2.Id), t)
+		assert.True(proto.Equal(a2.Id.Sum(b2.Id), a.Id.Sum(b.Id)), t)
+		assert.True(a2.Id.Fragments()+b2.Id.Fragments() <= a.Id.Fragments()+b.Id.Fragments(), t)
+		assert.True(proto.Equal(a2.Event, a.Event.Join(b.Event)), t)
+
+		stamps[i], stamps[j] = a2, b2
+	}
+
+	after := 0
+	sum := itc.NewId(0)
+	for _, s := range stamps {
+		after += s.Id.Fragments()
+		sum = sum.Sum(s.Id)
+	}
+	assert.True(after < before, t)
+	assert.True(proto.Equal(sum, itc.NewId(1)), t, sum.Print())
+}
@@ -0,0 +1,57 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func TestEventDeltaDominated(t *testing.T) {
+	e := &itc.Event{
+		IsLeaf: false,
+		Value:  1,
+		Left:   itc.NewEvent(2),
+		Right:  itc.NewEvent(0),
+	}
+
+	assert.True(proto.Equal(e.Delta(itc.NewEvent(5)), itc.NewEvent(1)), t)
+	assert.True(proto.Equal(e.Delta(e), itc.NewEvent(1)), t)
+}
+
+// A peer that is behind on one replica only receives that replica's branch
+func TestEventDeltaSmall(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	stamps := randomStamps(rng, 16)
+
+	event := itc.NewEvent(0)
+	for i, s := range stamps {
+		for j := 0; j < 1+rng.Intn(4); j++ {
+			s = s.Advance()
+		}
+		stamps[i] = s
+		event = event.Join(s.Event)
+	}
+
+	base := event
+	stamps[3] = itc.NewStamp(stamps[3].Id, event).Advance()
+	event = event.Join(stamps[3].Event)
+
+	delta := event.Delta(base)
+	assert.True(proto.Equal(base.ApplyDelta(delta), event), t)
+	assert.True(proto.Size(delta) < proto.Size(event), t)
+}
+
+func TestEventDeltaJoin(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 500; i++ {
+		e := randomEvent(rng, 5)
+		base := randomEvent(rng, 5)
+
+		delta := e.Delta(base)
+		assert.True(delta.Leq(e), t)
+		assert.True(proto.Equal(delta, delta.Norm()), t)
+		assert.True(proto.Equal(base.ApplyDelta(delta), base.Join(e)), t)
+	}
+}
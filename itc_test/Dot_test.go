@@ -0,0 +1,102 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func TestAdvanceWithDotSeed(t *testing.T) {
+	s, dot := itc.SeedStamp().AdvanceWithDot()
+
+	assert.True(proto.Equal(s, itc.SeedStamp().Advance()), t)
+	assert.True(proto.Equal(dot.Interval, itc.NewId(1)), t)
+	assert.True(dot.Counter == 1, t)
+	assert.True(s.Event.ContainsDot(dot), t)
+	assert.False(itc.NewEvent(0).ContainsDot(dot), t)
+}
+
+func TestAdvanceWithDotFork(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	a, da := a.AdvanceWithDot()
+	b, db := b.AdvanceWithDot()
+
+	assert.False(da.Equal(db), t)
+	assert.True(a.Event.ContainsDot(da), t)
+	assert.False(a.Event.ContainsDot(db), t)
+	assert.False(b.Event.ContainsDot(da), t)
+
+	j := a.Join(b)
+	assert.True(j.Event.ContainsDot(da), t)
+	assert.True(j.Event.ContainsDot(db), t)
+}
+
+func TestAdvanceWithDotAnonymous(t *testing.T) {
+	s := itc.NewStamp(itc.NewId(0), itc.NewEvent(3))
+	next, dot := s.AdvanceWithDot()
+
+	assert.True(proto.Equal(next, s), t)
+	assert.True(dot.IsZero(), t)
+	assert.False(next.Event.ContainsDot(dot), t)
+	assert.False(itc.SeedStamp().Advance().Event.ContainsDot(dot), t)
+	assert.True(dot.Print() == "0@0", t, dot.Print())
+
+	r := itc.MissingRange{Interval: itc.NewId(1), From: 0, To: 5}
+	assert.False(r.Contains(dot), t)
+}
+
+// Replicas advance, fork, join and sync at random. Every replica's event tree contains exactly the dots it has seen.
+func TestDotsMatchHistory(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	stamps := []*itc.Stamp{itc.SeedStamp()}
+	seen := []map[int]bool{{}}
+	var dots []itc.Dot
+
+	for step := 0; step < 400; step++ {
+		i := rng.Intn(len(stamps))
+		switch r := rng.Intn(10); {
+		case r < 5:
+			s, dot := stamps[i].AdvanceWithDot()
+			for _, d := range dots {
+				assert.False(d.Equal(dot), t, d.Print())
+			}
+			stamps[i] = s
+			seen[i][len(dots)] = true
+			dots = append(dots, dot)
+		case r < 6 && len(stamps) < 12:
+			a, b := stamps[i].Fork()
+			stamps[i] = a
+			stamps = append(stamps, b)
+			copied := map[int]bool{}
+			for d := range seen[i] {
+				copied[d] = true
+			}
+			seen = append(seen, copied)
+		case r < 7 && len(stamps) > 1:
+			j := rng.Intn(len(stamps) - 1)
+			if j >= i {
+				j++
+			}
+			stamps[i] = stamps[i].Join(stamps[j])
+			for d := range seen[j] {
+				seen[i][d] = true
+			}
+			stamps = append(stamps[:j], stamps[j+1:]...)
+			seen = append(seen[:j], seen[j+1:]...)
+		default:
+			j := rng.Intn(len(stamps))
+			stamps[i] = itc.NewStamp(stamps[i].Id, stamps[i].Event.Join(stamps[j].Event))
+			for d := range seen[j] {
+				seen[i][d] = true
+			}
+		}
+
+		for k, s := range stamps {
+			for d, dot := range dots {
+				assert.True(s.Event.ContainsDot(dot) == seen[k][d], t, dot.Print())
+			}
+		}
+	}
+}
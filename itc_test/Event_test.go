@@ -78,6 +78,26 @@ func TestEventLeqOneTree(t *testing.T){
 	assert.False(e2.Leq(e1),t) // Change order
 }
 
+func TestEventLeqTrees(t *testing.T){
+	e1 := &itc.Event{
+		IsLeaf:false,
+		Value:1,
+		Left: itc.NewEvent(2),
+		Right: itc.NewEvent(0),
+	}
+	e2 := &itc.Event{
+		IsLeaf:false,
+		Value:1,
+		Left: itc.NewEvent(0),
+		Right: itc.NewEvent(2),
+	}
+
+	assert.False(e1.Leq(e2),t)
+	assert.False(e2.Leq(e1),t)
+	assert.True(e1.Leq(e1.Join(e2)),t)
+	assert.True(e2.Leq(e1.Join(e2)),t)
+}
+
 // Norm
 func TestEventNormBasic(t *testing.T){
 	e := itc.NewEvent(3)
@@ -123,3 +143,22 @@ func TestEventJoinFlat(t *testing.T){
 	assert.True(proto.Equal(l.Join(r),expected),t)
 }
 
+func TestEventJoinTreeFlat(t *testing.T){
+	e1 := &itc.Event{
+		IsLeaf:false,
+		Value:1,
+		Left: itc.NewEvent(0),
+		Right: itc.NewEvent(2),
+	}
+	e2 := itc.NewEvent(2)
+	expected := &itc.Event{
+		IsLeaf:false,
+		Value:2,
+		Left: itc.NewEvent(0),
+		Right: itc.NewEvent(1),
+	}
+
+	assert.True(proto.Equal(e1.Join(e2),expected),t)
+	// The leaf is joined as (2,0,0) without being changed
+	assert.True(proto.Equal(e2,itc.NewEvent(2)),t)
+}
@@ -0,0 +1,116 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func TestFlatRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(48))
+
+	for _, s := range randomStamps(rng, 32) {
+		s = itc.NewStamp(s.Id, randomEvent(rng, 6))
+		flat := itc.Flatten(s)
+		assert.True(proto.Equal(flat.Stamp(), s), t, s.Id.Print(), s.Event.Print())
+	}
+
+	flat := itc.FlattenEvent(itc.NewEvent(3))
+	assert.True(len(flat) == 1 && flat[0] == itc.EventNode{Value: 3}, t)
+	assert.True(len(itc.FlattenId(itc.NewId(1))) == 1, t)
+}
+
+func TestFlatEvents(t *testing.T) {
+	rng := rand.New(rand.NewSource(48))
+
+	for k := 0; k < 500; k++ {
+		a, b := randomEvent(rng, 6), randomEvent(rng, 6)
+		fa, fb := itc.FlattenEvent(a), itc.FlattenEvent(b)
+
+		assert.True(fa.Leq(fb) == a.Leq(b), t, a.Print(), b.Print())
+		assert.True(fa.Leq(fa), t)
+		assert.True(proto.Equal(fa.Join(fb).Tree(), a.Join(b)), t, a.Print(), b.Print())
+		assert.True(fa.Join(fb).Equal(itc.FlattenEvent(a.Join(b))), t)
+	}
+}
+
+func TestFlatStamps(t *testing.T) {
+	rng := rand.New(rand.NewSource(48))
+
+	for k := 0; k < 50; k++ {
+		for _, s := range randomStamps(rng, 8) {
+			s = itc.NewStamp(s.Id, randomEvent(rng, 6))
+			flat := itc.Flatten(s)
+
+			assert.True(proto.Equal(flat.Fill().Tree(), s.Fill()), t, s.Id.Print(), s.Event.Print())
+
+			// Grow only sees filled events when advancing
+			s = itc.NewStamp(s.Id, s.Fill())
+			flat = itc.Flatten(s)
+			for _, policy := range policies {
+				e1, c1 := s.GrowWith(policy)
+				e2, c2 := flat.GrowWith(policy)
+				assert.True(c1 == c2 && proto.Equal(e2.Tree(), e1), t, s.Id.Print(), s.Event.Print())
+			}
+
+			assert.True(proto.Equal(flat.Advance().Stamp(), s.Advance()), t)
+		}
+	}
+}
+
+func TestFlatGrowComb(t *testing.T) {
+	// Both branches of the id own part of the interval at every level, so every level makes a choice
+	id, event := itc.NewId(1), itc.NewEvent(1)
+	for k := 0; k < 500; k++ {
+		id = &itc.Id{IsLeaf: false, Left: id, Right: &itc.Id{IsLeaf: false, Left: itc.NewId(0), Right: itc.NewId(1)}}
+		event = &itc.Event{IsLeaf: false, Value: 0, Left: event, Right: itc.NewEvent(uint32(k % 3))}
+	}
+	s := itc.NewStamp(id, event)
+	flat := itc.Flatten(s)
+
+	for _, policy := range policies {
+		e1, c1 := s.GrowWith(policy)
+		e2, c2 := flat.GrowWith(policy)
+		assert.True(c1 == c2 && proto.Equal(e2.Tree(), e1), t)
+	}
+}
+
+func BenchmarkFlat(b *testing.B) {
+	stamps := benchmarkStamps()
+	flats := make([]itc.FlatStamp, len(stamps))
+	events := make([]*itc.Event, len(stamps))
+	for i, s := range stamps {
+		flats[i] = itc.Flatten(s)
+		events[i] = s.Event
+	}
+	top := itc.JoinEvents(events...)
+	flatTop := itc.FlattenEvent(top)
+
+	cases := []struct {
+		name       string
+		tree, flat func(i, j int)
+	}{
+		{"Leq", func(i, _ int) { stamps[i].Event.Leq(top) }, func(i, _ int) { flats[i].Event.Leq(flatTop) }},
+		{"Join", func(i, j int) { stamps[i].Event.Join(stamps[j].Event) }, func(i, j int) { flats[i].Event.Join(flats[j].Event) }},
+		{"Fill", func(i, _ int) { stamps[i].Fill() }, func(i, _ int) { flats[i].Fill() }},
+		{"Grow", func(i, _ int) { stamps[i].Grow() }, func(i, _ int) { flats[i].Grow() }},
+	}
+
+	for _, c := range cases {
+		c := c
+		b.Run(c.name+"/tree", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.tree(i%len(stamps), (i+1)%len(stamps))
+			}
+		})
+		b.Run(c.name+"/flat", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.flat(i%len(stamps), (i+1)%len(stamps))
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+// The stamps must have disjoint, non-empty ids summing to the id and carry the event
+func checkForked(t *testing.T, stamp *itc.Stamp, stamps []*itc.Stamp) {
+	sum := itc.NewId(0)
+	for i, s := range stamps {
+		assert.False(s.Id.IsEmpty(), t)
+		assert.True(proto.Equal(s.Event, stamp.Event), t)
+		for _, o := range stamps[i+1:] {
+			assert.False(s.Id.Overlaps(o.Id), t)
+		}
+		sum = sum.Sum(s.Id)
+	}
+	assert.True(proto.Equal(sum, stamp.Id), t, sum.Print())
+}
+
+func TestForkN(t *testing.T) {
+	seed := itc.SeedStamp().Advance()
+
+	stamps := seed.ForkN(4)
+	assert.True(len(stamps) == 4, t)
+	checkForked(t, seed, stamps)
+	for _, s := range stamps {
+		assert.True(s.Id.Depth() == 2, t, s.Id.Print())
+	}
+
+	stamps = seed.ForkN(3)
+	checkForked(t, seed, stamps)
+	assert.True(stamps[0].Id.Print() == "(1,0)", t)
+	assert.True(stamps[1].Id.Print() == "(0,(1,0))", t)
+	assert.True(stamps[2].Id.Print() == "(0,(0,1))", t)
+
+	// As shallow as it can be, where chained forks would go 63 levels deep
+	for _, s := range seed.ForkN(64) {
+		assert.True(s.Id.Depth() == 6, t)
+	}
+
+	assert.True(len(seed.ForkN(1)) == 1, t)
+	assert.True(seed.ForkN(0) == nil, t)
+}
+
+func TestForkWeighted(t *testing.T) {
+	seed := itc.SeedStamp()
+
+	stamps := seed.ForkWeighted(3, 1)
+	checkForked(t, seed, stamps)
+	assert.True(stamps[0].Id.Print() == "(1,(1,0))", t)
+	assert.True(stamps[1].Id.Print() == "(0,(0,1))", t)
+
+	assert.True(seed.ForkWeighted() == nil, t)
+	assert.True(seed.ForkWeighted(1, 0) == nil, t)
+
+	// An anonymous stamp forks into anonymous stamps
+	for _, s := range itc.NewStamp(itc.NewId(0), seed.Event).ForkN(3) {
+		assert.True(s.Id.IsEmpty(), t)
+	}
+}
+
+func TestForkWeightedRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(44))
+
+	for _, stamp := range randomStamps(rng, 20) {
+		weights := make([]uint, 1+rng.Intn(6))
+		for i := range weights {
+			weights[i] = uint(1 + rng.Intn(10))
+		}
+
+		stamps := stamp.ForkWeighted(weights...)
+		assert.True(len(stamps) == len(weights), t)
+		checkForked(t, stamp, stamps)
+	}
+}
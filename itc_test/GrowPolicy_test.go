@@ -0,0 +1,89 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+var policies = []itc.GrowPolicy{itc.DefaultGrowPolicy, itc.ShallowGrowPolicy, itc.LargestSubtreeGrowPolicy}
+
+func TestAdvanceWithPolicies(t *testing.T) {
+	rng := rand.New(rand.NewSource(46))
+
+	for _, stamp := range randomStamps(rng, 64) {
+		stamp = itc.NewStamp(stamp.Id, randomEvent(rng, 4))
+		assert.True(proto.Equal(stamp.AdvanceWith(itc.DefaultGrowPolicy), stamp.Advance()), t)
+
+		for _, p := range policies {
+			next := stamp.AdvanceWith(p)
+			assert.True(stamp.Leq(next), t)
+			assert.False(next.Leq(stamp), t)
+			assert.True(proto.Equal(next.Id, stamp.Id), t)
+		}
+	}
+}
+
+func TestGrowPolicyChoice(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+	leaf := itc.NewEvent
+
+	// The id owns a point three levels down on the left, where the event tree already reaches, and a quarter on the
+	// right, under an event leaf
+	id := &itc.Id{Left: &itc.Id{Left: &itc.Id{Left: zero, Right: one}, Right: zero}, Right: &itc.Id{Left: zero, Right: one}}
+	left := &itc.Event{Left: &itc.Event{Left: leaf(0), Right: leaf(1)}, Right: leaf(0)}
+	stamp := itc.NewStamp(id, &itc.Event{Left: left, Right: leaf(0)})
+	assert.True(proto.Equal(stamp.Fill(), stamp.Event), t)
+
+	// The paper's policy avoids expanding the right leaf, the shallow policy finds the right shallower
+	paper := stamp.AdvanceWith(itc.DefaultGrowPolicy)
+	assert.True(paper.Event.Print() == "0,(0,(0,(0,2),0),0)", t, paper.Event.Print())
+
+	shallow := stamp.AdvanceWith(itc.ShallowGrowPolicy)
+	assert.True(shallow.Event.Print() == "0,(0,(0,(0,1),0),0,(0,1))", t, shallow.Event.Print())
+
+	// Pricing an expansion as one more level tips the choice back to the left, three levels against two and an
+	// expansion
+	_, cost := stamp.GrowWith(itc.CostPolicy{ExpandCost: 2, DescendCost: 1})
+	assert.True(cost == 3, t)
+}
+
+func TestGrowPolicyLargestSubtree(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+	node := func(l, r *itc.Id) *itc.Id { return &itc.Id{Left: l, Right: r} }
+
+	// The id owns a quarter on the left, its shallowest 1, and three eighths on the right, deeper down
+	deep := node(one, node(one, zero))
+	stamp := itc.NewStamp(node(node(one, zero), node(deep, deep)), itc.NewEvent(0))
+
+	shallow := stamp.AdvanceWith(itc.ShallowGrowPolicy)
+	assert.True(shallow.Event.Print() == "0,(0,(1,0),0)", t, shallow.Event.Print())
+	paper := stamp.AdvanceWith(itc.DefaultGrowPolicy)
+	assert.True(proto.Equal(paper, shallow), t, paper.Event.Print())
+
+	// The largest subtree policy follows the right, which owns more, then the heavier branch at every level
+	largest := stamp.AdvanceWith(itc.LargestSubtreeGrowPolicy)
+	assert.True(largest.Event.Print() == "0,(0,0,(0,0,(1,0)))", t, largest.Event.Print())
+	assert.True(stamp.Leq(largest), t)
+
+	// Both branches owning as much, the cost decides
+	even := itc.NewStamp(node(node(one, zero), node(zero, one)), itc.NewEvent(0))
+	assert.True(proto.Equal(even.AdvanceWith(itc.LargestSubtreeGrowPolicy), even.Advance()), t)
+}
+
+func TestGrowPolicyCost(t *testing.T) {
+	// Growing under an event leaf expands it, which the paper's policy prices far above a level
+	id := &itc.Id{Left: itc.NewId(1), Right: itc.NewId(0)}
+	stamp := itc.NewStamp(id, itc.NewEvent(2))
+
+	_, cost := stamp.Grow()
+	assert.True(cost == itc.GrowIncrement+1, t)
+	_, cost = stamp.GrowWith(itc.ShallowGrowPolicy)
+	assert.True(cost == 1, t)
+	_, cost = stamp.GrowWith(itc.CostPolicy{ExpandCost: 1, DescendCost: 1})
+	assert.True(cost == 2, t)
+	_, cost = stamp.GrowWith(itc.CostPolicy{ExpandCost: 5, DescendCost: 0})
+	assert.True(cost == 5, t)
+}
@@ -119,6 +119,27 @@ func TestIdSplitZeroOne(t *testing.T) {
 	assert.True(proto.Equal(b, expectedB), t)
 }
 
+func TestIdSplitOneZero(t *testing.T) {
+	i := &itc.Id{IsLeaf: false, Left: itc.NewId(1), Right: itc.NewId(0)}
+
+	a, b := i.Split()
+
+	expectedA := &itc.Id{IsLeaf: false, Left: &itc.Id{IsLeaf: false, Left: itc.NewId(1), Right: itc.NewId(0)}, Right: itc.NewId(0)}
+	expectedB := &itc.Id{IsLeaf: false, Left: &itc.Id{IsLeaf: false, Left: itc.NewId(0), Right: itc.NewId(1)}, Right: itc.NewId(0)}
+	assert.True(proto.Equal(a, expectedA), t, a.Print())
+	assert.True(proto.Equal(b, expectedB), t, b.Print())
+}
+
+func TestIdSplitBoth(t *testing.T) {
+	right := &itc.Id{IsLeaf: false, Left: itc.NewId(1), Right: itc.NewId(0)}
+	i := &itc.Id{IsLeaf: false, Left: itc.NewId(1), Right: right}
+
+	a, b := i.Split()
+
+	assert.True(proto.Equal(a, &itc.Id{IsLeaf: false, Left: itc.NewId(1), Right: itc.NewId(0)}), t, a.Print())
+	assert.True(proto.Equal(b, &itc.Id{IsLeaf: false, Left: itc.NewId(0), Right: right}), t, b.Print())
+}
+
 // Sum
 func TestIdSumSimple(t *testing.T) {
 	id1 := itc.NewId(0)
@@ -0,0 +1,169 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestInternShares(t *testing.T) {
+	in := itc.NewInterner()
+
+	a := &itc.Event{IsLeaf: false, Value: 1, Left: itc.NewEvent(0), Right: itc.NewEvent(2)}
+	b := &itc.Event{IsLeaf: false, Value: 1, Left: itc.NewEvent(0), Right: itc.NewEvent(2)}
+	ia, ib := in.Event(a), in.Event(b)
+	assert.True(ia == ib, t)
+	assert.True(proto.Equal(ia, a), t)
+	assert.True(in.Event(ia) == ia, t)
+
+	events, ids := in.Len()
+	assert.True(events == 3 && ids == 0, t)
+
+	s1, s2 := itc.SeedStamp().Fork()
+	i1, i2 := in.Id(s1.Id), in.Id(s2.Id)
+	// Both halves share the 0 and 1 leaves
+	assert.True(i1.Left == i2.Right && i1.Right == i2.Left, t)
+	_, ids = in.Len()
+	assert.True(ids == 4, t)
+
+	s := in.Stamp(s1.Advance())
+	assert.True(s.Id == i1, t)
+}
+
+func TestInternRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(49))
+	in := itc.NewInterner()
+
+	for k := 0; k < 500; k++ {
+		a, b := randomEvent(rng, 6), randomEvent(rng, 6)
+		ia, ib := in.Event(a), in.Event(b)
+
+		assert.True(in.Leq(ia, ib) == a.Leq(b), t, a.Print(), b.Print())
+		assert.True(in.Leq(ia, ia), t)
+
+		j := in.Join(a, b)
+		assert.True(proto.Equal(j, a.Join(b)), t, a.Print(), b.Print())
+		assert.True(in.Event(j) == j, t)
+		assert.True(in.Join(ia, ia) == ia, t)
+	}
+}
+
+func TestInternConcurrent(t *testing.T) {
+	rng := rand.New(rand.NewSource(49))
+	in := itc.NewInterner()
+
+	events := make([]*itc.Event, 64)
+	for i := range events {
+		events[i] = randomEvent(rng, 6)
+	}
+
+	interned := make([][]*itc.Event, 4)
+	var wg sync.WaitGroup
+	for w := range interned {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, e := range events {
+				interned[w] = append(interned[w], in.Join(e, e))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range events {
+		for w := range interned {
+			assert.True(interned[w][i] == interned[0][i], t)
+		}
+	}
+}
+
+// The stamps of a store: every replica's stamp after each round of a random fork-event-join history, each one
+// decoded separately as if read back from disk
+func storedStamps(rounds int) []*itc.Stamp {
+	rng := rand.New(rand.NewSource(49))
+	replicas := randomStamps(rng, 32)
+
+	var stored []*itc.Stamp
+	for r := 0; r < rounds; r++ {
+		i, j := rng.Intn(len(replicas)), rng.Intn(len(replicas))
+		replicas[i] = replicas[i].Advance()
+		if i != j {
+			replicas[j] = itc.NewStamp(replicas[j].Id, replicas[j].Event.Join(replicas[i].Event))
+		}
+		for _, s := range replicas {
+			b, _ := s.Encode()
+			decoded, _ := itc.DecodeStamp(b)
+			stored = append(stored, decoded)
+		}
+	}
+	return stored
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func BenchmarkInternMemory(b *testing.B) {
+	cases := []struct {
+		name string
+		keep func(stamps []*itc.Stamp) interface{}
+	}{
+		{"plain", func(stamps []*itc.Stamp) interface{} { return stamps }},
+		{"interned", func(stamps []*itc.Stamp) interface{} {
+			in := itc.NewInterner()
+			kept := make([]*itc.Stamp, len(stamps))
+			for i, s := range stamps {
+				kept[i] = in.Stamp(s)
+			}
+			return kept
+		}},
+	}
+
+	for _, c := range cases {
+		c := c
+		b.Run(c.name, func(b *testing.B) {
+			var perStamp float64
+			for i := 0; i < b.N; i++ {
+				before := heapInUse()
+				stamps := storedStamps(200)
+				kept := c.keep(stamps)
+				stamps = nil
+				after := heapInUse()
+				perStamp = float64(after-before) / float64(len(kept.([]*itc.Stamp)))
+				runtime.KeepAlive(kept)
+			}
+			b.ReportMetric(perStamp, "heap-B/stamp")
+		})
+	}
+}
+
+func BenchmarkInternLeq(b *testing.B) {
+	stamps := storedStamps(50)
+	in := itc.NewInterner()
+	interned := make([]*itc.Stamp, len(stamps))
+	for i, s := range stamps {
+		interned[i] = in.Stamp(s)
+	}
+	n := len(stamps)
+
+	b.Run("plain", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			stamps[i%n].Event.Leq(stamps[n-1-i%n].Event)
+		}
+	})
+	b.Run("interned", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			in.Leq(interned[i%n].Event, interned[n-1-i%n].Event)
+		}
+	})
+}
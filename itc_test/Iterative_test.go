@@ -0,0 +1,151 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func TestIterativeEvents(t *testing.T) {
+	rng := rand.New(rand.NewSource(47))
+
+	for k := 0; k < 500; k++ {
+		a, b := randomEvent(rng, 6), randomEvent(rng, 6)
+
+		assert.True(a.LeqIterative(b) == a.Leq(b), t, a.Print(), b.Print())
+		assert.True(proto.Equal(a.JoinIterative(b), a.Join(b)), t, a.Print(), b.Print())
+		assert.True(proto.Equal(a.MinIterative(), a.Min()), t)
+		assert.True(proto.Equal(a.MaxIterative(), a.Max()), t)
+
+		n := &itc.Event{IsLeaf: false, Value: 1, Left: a, Right: b}
+		assert.True(proto.Equal(n.NormIterative(), n.Norm()), t)
+	}
+}
+
+func TestIterativeStamps(t *testing.T) {
+	rng := rand.New(rand.NewSource(47))
+
+	for k := 0; k < 50; k++ {
+		for _, s := range randomStamps(rng, 8) {
+			s = itc.NewStamp(s.Id, randomEvent(rng, 6))
+
+			assert.True(proto.Equal(s.FillIterative(), s.Fill()), t, s.Id.Print(), s.Event.Print())
+
+			e1, c1 := s.Grow()
+			e2, c2 := s.GrowIterative()
+			assert.True(c1 == c2 && proto.Equal(e1, e2), t, s.Id.Print(), s.Event.Print())
+
+			// Grow only sees filled events when advancing
+			filled := itc.NewStamp(s.Id, s.Fill())
+			for _, policy := range policies {
+				e1, c1 = filled.GrowWith(policy)
+				e2, c2 = filled.GrowWithIterative(policy)
+				assert.True(c1 == c2 && proto.Equal(e1, e2), t, s.Id.Print(), filled.Event.Print())
+			}
+
+			i1, i2 := s.Id.Split()
+			j1, j2 := s.Id.SplitIterative()
+			assert.True(proto.Equal(i1, j1) && proto.Equal(i2, j2), t, s.Id.Print())
+		}
+	}
+}
+
+// An id owning the leftmost point at the given depth, and an event growing one level deeper at each step to the left
+func deep(depth int) (*itc.Id, *itc.Event) {
+	id, event := itc.NewId(1), itc.NewEvent(1)
+	for k := 0; k < depth; k++ {
+		id = &itc.Id{IsLeaf: false, Left: id, Right: itc.NewId(0)}
+		event = &itc.Event{IsLeaf: false, Value: 0, Left: event, Right: itc.NewEvent(0)}
+	}
+	return id, event
+}
+
+func TestIterativeDeep(t *testing.T) {
+	id, event := deep(2000)
+	s := itc.NewStamp(id, event)
+	other := s.Advance()
+
+	assert.True(event.LeqIterative(other.Event) == event.Leq(other.Event), t)
+	assert.True(proto.Equal(event.JoinIterative(other.Event), event.Join(other.Event)), t)
+	assert.True(proto.Equal(s.FillIterative(), s.Fill()), t)
+
+	// Far deeper than is comfortable for the recursive versions
+	id, event = deep(100000)
+	s = itc.NewStamp(id, event)
+	assert.True(event.LeqIterative(event), t)
+	assert.True(event.MaxIterative().Value == 1, t)
+	assert.True(proto.Equal(event.JoinIterative(event), event), t)
+
+	grown, _ := s.GrowIterative()
+	assert.True(event.LeqIterative(grown) && !grown.LeqIterative(event), t)
+
+	i1, i2 := id.SplitIterative()
+	assert.False(i1.Overlaps(i2), t)
+}
+
+func TestIterativeAllocs(t *testing.T) {
+	s := benchmarkStamps()[3]
+	s = itc.NewStamp(s.Id, s.Fill())
+
+	// Only the path to the new event is built, and the nodes of a split come from one allocation
+	grow := testing.AllocsPerRun(100, func() { s.Grow() })
+	growIterative := testing.AllocsPerRun(100, func() { s.GrowIterative() })
+	assert.True(growIterative == 1 && growIterative < grow, t)
+
+	split := testing.AllocsPerRun(100, func() { s.Id.Split() })
+	splitIterative := testing.AllocsPerRun(100, func() { s.Id.SplitIterative() })
+	assert.True(splitIterative == 1 && splitIterative < split, t)
+}
+
+func benchmarkStamps() []*itc.Stamp {
+	rng := rand.New(rand.NewSource(47))
+	stamps := randomStamps(rng, 16)
+	for i, s := range stamps {
+		stamps[i] = itc.NewStamp(s.Id, randomEvent(rng, 8))
+	}
+	return stamps
+}
+
+func BenchmarkIterative(b *testing.B) {
+	stamps := benchmarkStamps()
+	pair := func(i int) (*itc.Stamp, *itc.Stamp) {
+		return stamps[i%len(stamps)], stamps[(i+1)%len(stamps)]
+	}
+
+	// Comparing with an event that dominates walks the whole tree
+	events := make([]*itc.Event, len(stamps))
+	for i, s := range stamps {
+		events[i] = s.Event
+	}
+	top := itc.JoinEvents(events...)
+
+	cases := []struct {
+		name                 string
+		recursive, iterative func(s1, s2 *itc.Stamp)
+	}{
+		{"Leq", func(s1, _ *itc.Stamp) { s1.Event.Leq(top) }, func(s1, _ *itc.Stamp) { s1.Event.LeqIterative(top) }},
+		{"Join", func(s1, s2 *itc.Stamp) { s1.Event.Join(s2.Event) }, func(s1, s2 *itc.Stamp) { s1.Event.JoinIterative(s2.Event) }},
+		{"Max", func(s1, _ *itc.Stamp) { s1.Event.Max() }, func(s1, _ *itc.Stamp) { s1.Event.MaxIterative() }},
+		{"Fill", func(s1, _ *itc.Stamp) { s1.Fill() }, func(s1, _ *itc.Stamp) { s1.FillIterative() }},
+		{"Grow", func(s1, _ *itc.Stamp) { s1.Grow() }, func(s1, _ *itc.Stamp) { s1.GrowIterative() }},
+		{"Split", func(s1, _ *itc.Stamp) { s1.Id.Split() }, func(s1, _ *itc.Stamp) { s1.Id.SplitIterative() }},
+	}
+
+	for _, c := range cases {
+		c := c
+		b.Run(c.name+"/recursive", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.recursive(pair(i))
+			}
+		})
+		b.Run(c.name+"/iterative", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c.iterative(pair(i))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package itc_test
+
+import (
+	"errors"
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func joinPairwise(stamps []*itc.Stamp) *itc.Stamp {
+	s := stamps[0]
+	for _, o := range stamps[1:] {
+		s = s.Join(o)
+	}
+	return s
+}
+
+func TestJoinEventsRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(45))
+
+	for k := 0; k < 200; k++ {
+		events := make([]*itc.Event, 1+rng.Intn(6))
+		for i := range events {
+			events[i] = randomEvent(rng, 4)
+		}
+
+		expected := events[0]
+		for _, e := range events[1:] {
+			expected = expected.Join(e)
+		}
+		assert.True(proto.Equal(itc.JoinEvents(events...), expected), t, expected.Print())
+	}
+
+	assert.True(itc.JoinEvents() == nil, t)
+}
+
+func TestJoinAll(t *testing.T) {
+	rng := rand.New(rand.NewSource(45))
+
+	for k := 0; k < 50; k++ {
+		stamps := randomStamps(rng, 1+rng.Intn(8))
+		for i := range stamps {
+			for j := rng.Intn(3); j > 0; j-- {
+				stamps[i] = stamps[i].Advance()
+			}
+		}
+
+		joined, err := itc.JoinAll(stamps...)
+		assert.Nil(err, t)
+		assert.True(proto.Equal(joined, joinPairwise(stamps)), t)
+		assert.True(proto.Equal(joined.Id, itc.NewId(1)), t)
+	}
+
+	s, err := itc.JoinAll()
+	assert.True(s == nil && err == nil, t)
+}
+
+func TestJoinAllOverlap(t *testing.T) {
+	stamps := itc.SeedStamp().ForkN(4)
+	stamps = append(stamps, stamps[2])
+
+	_, err := itc.JoinAll(stamps...)
+	assert.True(errors.Is(err, itc.ErrOverlappingIds), t)
+	assert.True(err.Error() == "itc: ids overlap: stamps 2 and 4", t, err.Error())
+
+	_, err = itc.JoinAll(itc.SeedStamp(), stamps[0])
+	assert.True(errors.Is(err, itc.ErrOverlappingIds), t)
+}
+
+// Workers forked from one stamp, each having recorded a few events
+func workers(n int) []*itc.Stamp {
+	stamps := itc.SeedStamp().ForkN(n)
+	for i := range stamps {
+		for j := 0; j <= i%4; j++ {
+			stamps[i] = stamps[i].Advance()
+		}
+	}
+	return stamps
+}
+
+func BenchmarkJoinAll(b *testing.B) {
+	stamps := workers(64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		itc.JoinAll(stamps...)
+	}
+}
+
+func BenchmarkJoinPairwise(b *testing.B) {
+	stamps := workers(64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		joinPairwise(stamps)
+	}
+}
@@ -0,0 +1,82 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func TestEventMeetFlat(t *testing.T) {
+	assert.True(proto.Equal(itc.NewEvent(2).Meet(itc.NewEvent(5)), itc.NewEvent(2)), t)
+}
+
+func TestEventMeetTree(t *testing.T) {
+	e1 := &itc.Event{
+		IsLeaf: false,
+		Value:  1,
+		Left:   itc.NewEvent(3),
+		Right:  itc.NewEvent(0),
+	}
+	e2 := &itc.Event{
+		IsLeaf: false,
+		Value:  2,
+		Left:   itc.NewEvent(0),
+		Right:  itc.NewEvent(1),
+	}
+
+	// Pointwise (4,1) and (2,3) give (2,1)
+	expected := &itc.Event{
+		IsLeaf: false,
+		Value:  1,
+		Left:   itc.NewEvent(1),
+		Right:  itc.NewEvent(0),
+	}
+	assert.True(proto.Equal(e1.Meet(e2), expected), t)
+	assert.True(proto.Equal(e2.Meet(e1), expected), t)
+
+	// Meet with a flat event below both branches flattens the result
+	assert.True(proto.Equal(e1.Meet(itc.NewEvent(1)), itc.NewEvent(1)), t)
+}
+
+// The meet is below every input, in normal form, and above anything below every input
+func TestEventMeetProperties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		a := randomEvent(rng, 4)
+		b := randomEvent(rng, 4)
+		m := a.Meet(b)
+
+		assert.True(m.Leq(a), t)
+		assert.True(m.Leq(b), t)
+		assert.True(proto.Equal(m, m.Norm()), t)
+		assert.True(proto.Equal(m, b.Meet(a)), t)
+
+		c := randomEvent(rng, 4)
+		if c.Leq(a) && c.Leq(b) {
+			assert.True(c.Leq(m), t)
+		}
+		assert.True(c.Meet(a).Leq(m) == c.Meet(a).Leq(b), t)
+	}
+}
+
+func TestMeetAll(t *testing.T) {
+	assert.True(itc.MeetAll() == nil, t)
+
+	rng := rand.New(rand.NewSource(2))
+	events := []*itc.Event{randomEvent(rng, 4), randomEvent(rng, 4), randomEvent(rng, 4)}
+	m := itc.MeetAll(events...)
+	assert.True(proto.Equal(m, events[0].Meet(events[1]).Meet(events[2])), t)
+}
+
+func TestMeetStamps(t *testing.T) {
+	a, b := itc.SeedStamp().Advance().Fork()
+	a = a.Advance().Advance()
+	b = b.Advance()
+
+	m := itc.MeetStamps(a, b)
+	assert.True(m.Leq(a.Event), t)
+	assert.True(m.Leq(b.Event), t)
+	assert.True(proto.Equal(m, a.Event.Meet(b.Event)), t)
+}
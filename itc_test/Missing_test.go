@@ -0,0 +1,61 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+	"testing"
+)
+
+func TestMissingNone(t *testing.T) {
+	e := itc.NewEvent(3)
+	assert.False(e.Missing(itc.NewEvent(2)).Next(), t)
+	assert.False(e.Missing(e).Next(), t)
+}
+
+func TestMissingFork(t *testing.T) {
+	a, b := itc.SeedStamp().Advance().Fork()
+	b, d1 := b.AdvanceWithDot()
+	b, d2 := b.AdvanceWithDot()
+
+	ranges := a.Event.Missing(b.Event).All()
+	assert.True(len(ranges) == 1, t)
+
+	r := ranges[0]
+	assert.True(r.From == 2 && r.To == 3, t)
+	assert.True(r.Contains(d1), t)
+	assert.True(r.Contains(d2), t)
+
+	_, d3 := a.AdvanceWithDot()
+	assert.False(r.Contains(d3), t)
+}
+
+// The ranges cover exactly what the peer has beyond us, without overlapping
+func TestMissingCover(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		mine := randomEvent(rng, 5)
+		peer := randomEvent(rng, 5)
+
+		covered := mine
+		seen := itc.NewId(0)
+		for it := mine.Missing(peer); it.Next(); {
+			r := it.Range()
+			assert.True(r.From <= r.To, t)
+			assert.False(r.Interval.Overlaps(seen), t)
+			seen = seen.Sum(r.Interval)
+
+			lo, _ := mine.MinOver(r.Interval)
+			hi, _ := mine.MaxOver(r.Interval)
+			assert.True(lo == hi && hi+1 == r.From, t)
+			lo, _ = peer.MinOver(r.Interval)
+			hi, _ = peer.MaxOver(r.Interval)
+			assert.True(lo == hi && hi == r.To, t)
+
+			covered = covered.Join(itc.EventOver(r.Interval, r.To))
+		}
+
+		assert.True(proto.Equal(covered, mine.Join(peer)), t)
+	}
+}
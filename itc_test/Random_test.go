@@ -0,0 +1,36 @@
+package itc_test
+
+import (
+	"github.com/ziglet.io/go-itc/itc"
+	"math/rand"
+)
+
+// Generate a random normalised event tree no deeper than depth
+func randomEvent(rng *rand.Rand, depth int) *itc.Event {
+	if depth == 0 || rng.Intn(3) == 0 {
+		return itc.NewEvent(uint32(rng.Intn(5)))
+	}
+
+	e := &itc.Event{
+		IsLeaf: false,
+		Value:  uint32(rng.Intn(3)),
+		Left:   randomEvent(rng, depth-1),
+		Right:  randomEvent(rng, depth-1),
+	}
+	return e.Norm()
+}
+
+// Fork the seed stamp into n stamps with disjoint ids, advancing them at random along the way
+func randomStamps(rng *rand.Rand, n int) []*itc.Stamp {
+	stamps := []*itc.Stamp{itc.SeedStamp()}
+	for len(stamps) < n {
+		i := rng.Intn(len(stamps))
+		for j := rng.Intn(3); j > 0; j-- {
+			stamps[i] = stamps[i].Advance()
+		}
+		a, b := stamps[i].Fork()
+		stamps[i] = a
+		stamps = append(stamps, b)
+	}
+	return stamps
+}
@@ -0,0 +1,82 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"testing"
+)
+
+func TestIdOverlaps(t *testing.T) {
+	a, b := itc.NewId(1).Split()
+	c, d := b.Split()
+
+	assert.False(a.Overlaps(b), t)
+	assert.False(c.Overlaps(d), t)
+	assert.True(b.Overlaps(c), t)
+	assert.True(itc.NewId(1).Overlaps(d), t)
+	assert.False(itc.NewId(0).Overlaps(a), t)
+}
+
+func TestIdComplement(t *testing.T) {
+	a, b := itc.NewId(1).Split()
+	c, d := b.Split()
+
+	assert.True(proto.Equal(a.Complement(), b), t)
+	assert.True(proto.Equal(itc.NewId(1).Complement(), itc.NewId(0)), t)
+	assert.True(proto.Equal(itc.NewId(0).Complement(), itc.NewId(1)), t)
+
+	// The complement of the union of some ids is the union of the rest
+	assert.True(proto.Equal(a.Sum(c).Complement(), d), t)
+	assert.False(c.Overlaps(c.Complement()), t)
+	assert.True(proto.Equal(c.Sum(c.Complement()), itc.NewId(1)), t)
+}
+
+func TestIdNormDeep(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+	a := &itc.Id{Left: &itc.Id{Left: zero, Right: zero}, Right: one}
+	b := &itc.Id{Left: &itc.Id{Left: one, Right: one}, Right: &itc.Id{Left: zero, Right: zero}}
+
+	assert.True(proto.Equal(a.NormDeep(), &itc.Id{Left: zero, Right: one}), t, a.NormDeep().Print())
+	assert.True(proto.Equal(b.NormDeep(), &itc.Id{Left: one, Right: zero}), t, b.NormDeep().Print())
+	assert.True(proto.Equal((&itc.Id{Left: a, Right: zero}).NormDeep(), &itc.Id{Left: a.NormDeep(), Right: zero}), t)
+	assert.True(proto.Equal((&itc.Id{Left: b, Right: b}).NormDeep(), &itc.Id{Left: b.NormDeep(), Right: b.NormDeep()}), t)
+	assert.True(proto.Equal(a.NormDeep().Sum(b.NormDeep()), one), t)
+}
+
+func TestEventOver(t *testing.T) {
+	a, _ := itc.NewId(1).Split()
+
+	expected := &itc.Event{
+		IsLeaf: false,
+		Value:  0,
+		Left:   itc.NewEvent(3),
+		Right:  itc.NewEvent(0),
+	}
+	assert.True(proto.Equal(itc.EventOver(a, 3), expected), t)
+	assert.True(proto.Equal(itc.EventOver(itc.NewId(1), 3), itc.NewEvent(3)), t)
+}
+
+func TestEventMinMaxOver(t *testing.T) {
+	a, b := itc.NewId(1).Split()
+	e := &itc.Event{
+		IsLeaf: false,
+		Value:  1,
+		Left:   itc.NewEvent(2),
+		Right: &itc.Event{
+			IsLeaf: false,
+			Value:  0,
+			Left:   itc.NewEvent(0),
+			Right:  itc.NewEvent(4),
+		},
+	}
+
+	n, ok := e.MinOver(a)
+	assert.True(ok && n == 3, t)
+	n, ok = e.MinOver(b)
+	assert.True(ok && n == 1, t)
+	n, ok = e.MaxOver(b)
+	assert.True(ok && n == 5, t)
+	_, ok = e.MinOver(itc.NewId(0))
+	assert.False(ok, t)
+}
@@ -0,0 +1,129 @@
+package itc_test
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStabilityFrontier(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	tracker := itc.NewStabilityTracker("a", "b")
+
+	var moves []*itc.Event
+	tracker.Subscribe(func(e *itc.Event) {
+		moves = append(moves, e)
+	})
+
+	a = a.Advance()
+	assert.Nil(tracker.Observe("a", a.Event), t)
+	assert.True(proto.Equal(tracker.Frontier(), itc.NewEvent(0)), t)
+	assert.True(len(moves) == 0, t)
+	assert.False(tracker.IsStable(a.Event), t)
+
+	// b learns of a's event
+	b = itc.NewStamp(b.Id, b.Event.Join(a.Event)).Advance()
+	assert.Nil(tracker.Observe("b", b.Event), t)
+	assert.True(len(moves) == 1, t)
+	assert.True(tracker.IsStable(a.Event), t)
+	assert.False(tracker.IsStable(b.Event), t)
+	assert.True(proto.Equal(moves[0], a.Event.Meet(b.Event)), t)
+
+	// Observing something older does not move the frontier back
+	assert.Nil(tracker.Observe("b", itc.NewEvent(0)), t)
+	assert.True(len(moves) == 1, t)
+}
+
+func TestStabilityMembership(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	a = a.Advance()
+	tracker := itc.NewStabilityTracker("a", "b")
+	assert.Nil(tracker.Observe("a", a.Event), t)
+
+	assert.True(tracker.Observe("c", a.Event) == itc.ErrUnknownReplica, t)
+
+	// Retiring b leaves a as the only replica
+	tracker.RemoveReplica("b")
+	assert.True(proto.Equal(tracker.Frontier(), a.Event), t)
+
+	c, _ := b.Fork()
+	tracker.AddReplica("c", c.Event)
+	assert.True(proto.Equal(tracker.Frontier(), itc.NewEvent(0)), t)
+
+	tracker.RemoveReplica("a")
+	tracker.RemoveReplica("c")
+	assert.True(tracker.Frontier() == nil, t)
+}
+
+func TestStabilityWatch(t *testing.T) {
+	tracker := itc.NewStabilityTracker("a")
+	ch, cancel := tracker.Watch()
+
+	assert.Nil(tracker.Observe("a", itc.NewEvent(1)), t)
+	assert.Nil(tracker.Observe("a", itc.NewEvent(2)), t)
+
+	// Only the latest frontier is kept for a slow reader
+	assert.True(proto.Equal(<-ch, itc.NewEvent(2)), t)
+
+	cancel()
+	_, ok := <-ch
+	assert.False(ok, t)
+	cancel()
+}
+
+func TestStabilityOrdered(t *testing.T) {
+	tracker := itc.NewStabilityTracker("a")
+
+	// Callbacks never overlap so the slice needs no lock. The pause lets updates pile up behind a delivery.
+	var moves []*itc.Event
+	tracker.Subscribe(func(e *itc.Event) {
+		time.Sleep(10 * time.Microsecond)
+		moves = append(moves, e)
+	})
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for k := 1; k <= 200; k++ {
+		k := k
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			assert.Nil(tracker.Observe("a", itc.NewEvent(uint32(k))), t)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.True(len(moves) > 0, t)
+	for i := 1; i < len(moves); i++ {
+		assert.True(moves[i-1].Leq(moves[i]), t, moves[i-1].Print(), moves[i].Print())
+	}
+	assert.True(proto.Equal(moves[len(moves)-1], itc.NewEvent(200)), t)
+}
+
+func TestStabilityCallbackPanics(t *testing.T) {
+	tracker := itc.NewStabilityTracker("a")
+
+	var moves []*itc.Event
+	tracker.Subscribe(func(e *itc.Event) {
+		if e.Value == 1 {
+			panic("subscriber failed")
+		}
+		moves = append(moves, e)
+	})
+
+	func() {
+		defer func() {
+			assert.True(recover() != nil, t)
+		}()
+		tracker.Observe("a", itc.NewEvent(1))
+	}()
+
+	// The tracker is not left delivering, so later frontiers still arrive
+	assert.Nil(tracker.Observe("a", itc.NewEvent(2)), t)
+	assert.True(len(moves) == 1 && proto.Equal(moves[0], itc.NewEvent(2)), t)
+}
@@ -59,4 +59,59 @@ func TestStampTreeLeft(t *testing.T){
     assert.True(s1.Leq(s2),t)
 }
 
-// TODO Should test Case 4 but I'm not certain I understand it
\ No newline at end of file
+func TestStampTreeBoth(t *testing.T){
+    e1 := &itc.Event{
+        IsLeaf:false,
+        Value:1,
+        Left:itc.NewEvent(2),
+        Right:itc.NewEvent(0),
+    }
+    e2 := &itc.Event{
+        IsLeaf:false,
+        Value:2,
+        Left:itc.NewEvent(1),
+        Right:itc.NewEvent(0),
+    }
+    s1 := itc.NewStamp(itc.NewId(0),e1)
+    s2 := itc.NewStamp(itc.NewId(0),e2)
+
+    assert.True(s1.Leq(s2),t)
+    assert.False(s2.Leq(s1),t)
+
+    // Raising the right branch of s1 makes the two concurrent
+    s1.Event.Right = itc.NewEvent(2)
+    assert.False(s1.Leq(s2),t)
+    assert.False(s2.Leq(s1),t)
+}
+
+// Fill
+func TestStampFillBoth(t *testing.T){
+    // Both halves of the id are trees so neither case 4 nor case 5 applies
+    id := &itc.Id{
+        IsLeaf:false,
+        Left:&itc.Id{IsLeaf:false, Left:itc.NewId(1), Right:itc.NewId(0)},
+        Right:&itc.Id{IsLeaf:false, Left:itc.NewId(0), Right:itc.NewId(1)},
+    }
+    e := &itc.Event{
+        IsLeaf:false,
+        Value:0,
+        Left:&itc.Event{IsLeaf:false, Value:0, Left:itc.NewEvent(0), Right:itc.NewEvent(2)},
+        Right:&itc.Event{IsLeaf:false, Value:0, Left:itc.NewEvent(2), Right:itc.NewEvent(0)},
+    }
+    s := itc.NewStamp(id,e)
+
+    assert.True(proto.Equal(s.Fill(),itc.NewEvent(2)),t)
+}
+
+// Advance
+func TestStampAdvanceFills(t *testing.T){
+    id := &itc.Id{IsLeaf:false, Left:itc.NewId(1), Right:itc.NewId(0)}
+    e := &itc.Event{IsLeaf:false, Value:0, Left:itc.NewEvent(0), Right:itc.NewEvent(1)}
+    s := itc.NewStamp(id,e)
+
+    // The left half catches up with the right instead of growing
+    a := s.Advance()
+    assert.True(proto.Equal(a.Event,itc.NewEvent(1)),t)
+    assert.True(s.Leq(a),t)
+    assert.False(a.Leq(s),t)
+}
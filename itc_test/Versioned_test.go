@@ -0,0 +1,45 @@
+package itc_test
+
+import (
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"testing"
+)
+
+func TestVersionedDominated(t *testing.T) {
+	s1 := itc.SeedStamp().Advance()
+	s2 := s1.Advance()
+
+	v := itc.NewVersioned[string]().Put("one", s1).Put("two", s2)
+	assert.False(v.Conflict(), t)
+	assert.True(v.Values()[0] == "two", t)
+
+	// An older write arriving late is dropped
+	v = v.Put("one", s1)
+	assert.False(v.Conflict(), t)
+	assert.True(v.Values()[0] == "two", t)
+}
+
+func TestVersionedSiblings(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	a = a.Advance()
+	b = b.Advance()
+
+	va := itc.NewVersioned[string]().Put("a", a)
+	vb := itc.NewVersioned[string]().Put("b", b)
+	v := va.Merge(vb)
+
+	assert.True(v.Conflict(), t)
+	assert.True(len(v.Siblings()) == 2, t)
+	assert.True(v.Values()[0] == "a" && v.Values()[1] == "b", t)
+
+	// Merging is idempotent and the original containers are untouched
+	assert.True(len(v.Merge(vb).Siblings()) == 2, t)
+	assert.False(va.Conflict(), t)
+
+	// Resolving at a supersedes both siblings, even on b's side
+	r, a := v.Resolve("ab", a)
+	assert.False(r.Conflict(), t)
+	assert.True(vb.Merge(r).Values()[0] == "ab", t)
+	assert.True(b.Leq(a), t)
+}
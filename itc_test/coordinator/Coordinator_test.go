@@ -0,0 +1,228 @@
+package coordinator_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/coordinator"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// The ids must be pairwise disjoint and, with the free id, sum to the seed
+func checkPartition(t *testing.T, c *coordinator.Coordinator) {
+	ids := []*itc.Id{c.Free()}
+	for _, r := range c.Replicas() {
+		ids = append(ids, c.Lease(r))
+	}
+
+	sum := itc.NewId(0)
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			assert.False(a.Overlaps(b), t, a.Print(), b.Print())
+		}
+		sum = sum.Sum(a)
+	}
+	assert.True(proto.Equal(sum, itc.NewId(1)), t, sum.Print())
+}
+
+func TestCoordinatorAcquireRelease(t *testing.T) {
+	c, err := coordinator.NewCoordinator(coordinator.NewMemoryStore())
+	assert.Nil(err, t)
+
+	a, err := c.Acquire("a")
+	assert.Nil(err, t)
+	b, err := c.Acquire("b")
+	assert.Nil(err, t)
+	assert.False(a.Overlaps(b), t)
+	checkPartition(t, c)
+
+	// Acquiring again returns the same lease
+	again, err := c.Acquire("a")
+	assert.Nil(err, t)
+	assert.True(proto.Equal(a, again), t)
+
+	assert.Nil(c.Release("a", a), t)
+	assert.Nil(c.Release("b", b), t)
+	assert.True(c.Release("b", b) == coordinator.ErrUnknownReplica, t)
+
+	// Every id came back
+	assert.True(proto.Equal(c.Free(), itc.NewId(1)), t, c.Free().Print())
+	assert.True(len(c.Replicas()) == 0, t)
+}
+
+func TestCoordinatorReleaseCurrentId(t *testing.T) {
+	c, _ := coordinator.NewCoordinator(coordinator.NewMemoryStore())
+	a, _ := c.Acquire("a")
+	b, _ := c.Acquire("b")
+
+	// The replicas trade their shares between themselves, so their ids no longer match the leases
+	b, a = a.Sum(b).Split()
+	assert.False(proto.Equal(a, c.Lease("a")), t)
+
+	// An id overlapping the free one is refused and changes nothing
+	assert.True(c.Release("a", a.Sum(c.Free())) == coordinator.ErrOverlap, t)
+	assert.True(len(c.Replicas()) == 2, t)
+
+	assert.Nil(c.Release("a", a), t)
+	assert.Nil(c.Release("b", b), t)
+	assert.True(proto.Equal(c.Free(), itc.NewId(1)), t, c.Free().Print())
+}
+
+func TestCoordinatorStampsStayDisjoint(t *testing.T) {
+	c, _ := coordinator.NewCoordinator(coordinator.NewMemoryStore())
+
+	a, _ := c.Acquire("a")
+	b, _ := c.Acquire("b")
+	sa := itc.NewStamp(a, itc.NewEvent(0)).Advance()
+	sb := itc.NewStamp(b, itc.NewEvent(0)).Advance()
+
+	// Events recorded under leased ids are concurrent, never mistaken for one another
+	assert.False(sa.Leq(sb), t)
+	assert.False(sb.Leq(sa), t)
+}
+
+func TestCoordinatorConcurrent(t *testing.T) {
+	c, _ := coordinator.NewCoordinator(coordinator.NewMemoryStore())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := fmt.Sprintf("r%d", i)
+			id, err := c.Acquire(r)
+			assert.Nil(err, t)
+			if i%2 == 0 {
+				assert.Nil(c.Release(r, id), t)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.True(len(c.Replicas()) == 8, t)
+	checkPartition(t, c)
+}
+
+func TestCoordinatorFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coordinator")
+
+	c, err := coordinator.NewCoordinator(coordinator.NewFileStore(path))
+	assert.Nil(err, t)
+	a, _ := c.Acquire("a")
+	b, _ := c.Acquire("b")
+	c.Release("b", b)
+
+	// A restarted coordinator remembers its leases
+	c, err = coordinator.NewCoordinator(coordinator.NewFileStore(path))
+	assert.Nil(err, t)
+	assert.True(proto.Equal(c.Lease("a"), a), t)
+	assert.True(c.Lease("b") == nil, t)
+	checkPartition(t, c)
+}
+
+type failingStore struct {
+	coordinator.MemoryStore
+	fail bool
+}
+
+func (s *failingStore) Save(state []byte) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	return s.MemoryStore.Save(state)
+}
+
+func TestCoordinatorStoreFailure(t *testing.T) {
+	store := &failingStore{}
+	c, _ := coordinator.NewCoordinator(store)
+	a, _ := c.Acquire("a")
+
+	store.fail = true
+	_, err := c.Acquire("b")
+	assert.Err(err, t)
+	assert.Err(c.Release("a", a), t)
+
+	// Nothing changed
+	assert.True(proto.Equal(c.Lease("a"), a), t)
+	assert.True(c.Lease("b") == nil, t)
+	checkPartition(t, c)
+}
+
+func TestClientLocal(t *testing.T) {
+	c, _ := coordinator.NewCoordinator(coordinator.NewMemoryStore())
+	client := coordinator.NewLocalClient(c, coordinator.Options{})
+	defer client.Close()
+
+	a, err := client.Acquire("a")
+	assert.Nil(err, t)
+	assert.True(proto.Equal(a, c.Lease("a")), t)
+
+	assert.True(client.Release("b", a) == coordinator.ErrUnknownReplica, t)
+	assert.True(client.Release("a", itc.NewId(1)) == coordinator.ErrOverlap, t)
+	assert.Nil(client.Release("a", a), t)
+	assert.True(proto.Equal(c.Free(), itc.NewId(1)), t)
+
+	client.Close()
+	_, err = client.Acquire("a")
+	assert.True(err == coordinator.ErrClosed, t)
+}
+
+func TestClientRemoteError(t *testing.T) {
+	store := &failingStore{fail: true}
+	c, _ := coordinator.NewCoordinator(store)
+	client := coordinator.NewLocalClient(c, coordinator.Options{})
+	defer client.Close()
+
+	_, err := client.Acquire("a")
+	assert.True(err != nil && err.Error() == "disk full", t)
+}
+
+// Accepts every request and fails to read the response
+type deafConn struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *deafConn) Read([]byte) (int, error) {
+	return 0, errors.New("timeout")
+}
+
+func (c *deafConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestClientReadFailure(t *testing.T) {
+	conn := &deafConn{}
+	client := coordinator.NewClient(conn, coordinator.Options{})
+
+	// The response may still be on its way, so the connection cannot be used for another request
+	_, err := client.Acquire("a")
+	assert.True(err != nil && err.Error() == "timeout", t)
+	assert.True(conn.closed, t)
+
+	_, err = client.Acquire("a")
+	assert.True(err == coordinator.ErrClosed, t)
+}
+
+func TestServeConnFrameTooLarge(t *testing.T) {
+	c, _ := coordinator.NewCoordinator(coordinator.NewMemoryStore())
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint32(1<<10))
+	err := coordinator.ServeConn(&b, c, coordinator.Options{MaxFrame: 1 << 8})
+	assert.True(err == coordinator.ErrFrameTooLarge, t)
+
+	// Under the default limit the same frame is read, and only then found malformed
+	b.Reset()
+	binary.Write(&b, binary.BigEndian, uint32(1<<10))
+	b.Write(make([]byte, 1<<10))
+	err = coordinator.ServeConn(&b, c, coordinator.Options{})
+	assert.True(err == coordinator.ErrMalformed, t)
+}
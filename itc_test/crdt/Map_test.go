@@ -0,0 +1,128 @@
+package crdt_test
+
+import (
+	"encoding/gob"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/crdt"
+	"testing"
+)
+
+func init() {
+	gob.Register(crdt.NewLWWRegister[string]())
+	gob.Register(crdt.NewORSet[string]())
+}
+
+func TestMapNested(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	m := crdt.NewMap()
+
+	name, a := crdt.NewLWWRegister[string]().Set(a, "cart")
+	m, a, err := m.Put(a, "name", name)
+	assert.Nil(err, t)
+
+	items, a := crdt.NewORSet[string]().Add(a, "apple")
+	m, a, err = m.Put(a, "items", items)
+	assert.Nil(err, t)
+
+	// b adds an item and counts it concurrently with a adding another
+	mb := m
+	itemsB, _ := crdt.Lookup[*crdt.ORSet[string]](mb, "items")
+	itemsB, b = itemsB.Add(b, "pear")
+	mb, b, err = mb.Put(b, "items", itemsB)
+	assert.Nil(err, t)
+	mb, _, err = mb.Put(b, "count", crdt.NewPNCounter().Increment(b.Id, 1))
+	assert.Nil(err, t)
+
+	itemsA, _ := crdt.Lookup[*crdt.ORSet[string]](m, "items")
+	itemsA, a = itemsA.Add(a, "plum")
+	m, _, err = m.Put(a, "items", itemsA)
+	assert.Nil(err, t)
+
+	merged, err := m.Merge(mb)
+	assert.Nil(err, t)
+	assert.True(len(merged.Keys()) == 3, t)
+
+	all, ok := crdt.Lookup[*crdt.ORSet[string]](merged, "items")
+	assert.True(ok, t)
+	assert.True(all.Len() == 3, t)
+	count, ok := crdt.Lookup[*crdt.PNCounter](merged, "count")
+	assert.True(ok && count.Value() == 1, t)
+	_, ok = crdt.Lookup[*crdt.PNCounter](merged, "items")
+	assert.False(ok, t)
+}
+
+func TestMapRemove(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	m, a, err := crdt.NewMap().Put(a, "count", crdt.NewPNCounter().Increment(a.Id, 1))
+	assert.Nil(err, t)
+
+	removed := m.Remove("count")
+	_, ok := removed.Get("count")
+	assert.False(ok, t)
+
+	// A concurrent update keeps the key alive
+	c, _ := crdt.Lookup[*crdt.PNCounter](m, "count")
+	updated, _, err := m.Put(b, "count", c.Increment(b.Id, 1))
+	assert.Nil(err, t)
+	merged, err := removed.Merge(updated)
+	assert.Nil(err, t)
+	c, ok = crdt.Lookup[*crdt.PNCounter](merged, "count")
+	assert.True(ok && c.Value() == 2, t)
+
+	// Without one the remove wins
+	merged, err = removed.Merge(m)
+	assert.Nil(err, t)
+	assert.True(len(merged.Keys()) == 0, t)
+}
+
+func TestMapTypeMismatch(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	m1, _, err := crdt.NewMap().Put(a, "x", crdt.NewPNCounter())
+	assert.Nil(err, t)
+	m2, b, err := crdt.NewMap().Put(b, "x", crdt.NewORSet[string]())
+	assert.Nil(err, t)
+
+	_, err = m1.Merge(m2)
+	assert.True(err == crdt.ErrTypeMismatch, t)
+	_, _, err = m2.Put(b, "x", crdt.NewPNCounter())
+	assert.True(err == crdt.ErrTypeMismatch, t)
+}
+
+func TestMapPutAnonymous(t *testing.T) {
+	m := crdt.NewMap()
+	anon := itc.NewStamp(itc.NewId(0), itc.NewEvent(0))
+
+	_, _, err := m.Put(anon, "count", crdt.NewPNCounter())
+	assert.True(err == crdt.ErrAnonymous, t)
+	assert.True(len(m.Keys()) == 0, t)
+	_, ok := m.Get("count")
+	assert.False(ok, t)
+}
+
+func TestMapEncode(t *testing.T) {
+	s := itc.SeedStamp()
+	inner, s, err := crdt.NewMap().Put(s, "count", crdt.NewPNCounter().Increment(s.Id, 2))
+	assert.Nil(err, t)
+	name, s := crdt.NewLWWRegister[string]().Set(s, "x")
+	m, s, err := crdt.NewMap().Put(s, "inner", inner)
+	assert.Nil(err, t)
+	m, _, err = m.Put(s, "name", name)
+	assert.Nil(err, t)
+
+	bytes, err := m.Encode()
+	assert.Nil(err, t)
+	decoded, err := crdt.DecodeMap(bytes)
+	assert.Nil(err, t)
+	assert.True(len(decoded.Keys()) == 2, t)
+
+	n, ok := crdt.Lookup[*crdt.LWWRegister[string]](decoded, "name")
+	assert.True(ok, t)
+	v, _ := n.Get()
+	assert.True(v == "x", t)
+
+	i, ok := crdt.Lookup[*crdt.Map](decoded, "inner")
+	assert.True(ok, t)
+	c, ok := crdt.Lookup[*crdt.PNCounter](i, "count")
+	assert.True(ok && c.Value() == 2, t)
+}
@@ -0,0 +1,151 @@
+package crdt_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/crdt"
+	"math/rand"
+	"testing"
+)
+
+func TestORSetAddRemove(t *testing.T) {
+	s := crdt.NewORSet[string]()
+	stamp := itc.SeedStamp()
+
+	s, stamp = s.Add(stamp, "x")
+	s, stamp = s.Add(stamp, "y")
+	assert.True(s.Contains("x") && s.Contains("y"), t)
+	assert.True(s.Len() == 2, t)
+
+	s = s.Remove("x")
+	assert.False(s.Contains("x"), t)
+	assert.True(s.Len() == 1, t)
+
+	// Re-adding tags the element with a new dot
+	s2, _ := s.Add(stamp, "y")
+	assert.True(len(s2.Dots("y")) == 1, t)
+	assert.False(s2.Dots("y")[0].Equal(s.Dots("y")[0]), t)
+}
+
+func TestORSetAddAnonymous(t *testing.T) {
+	s := crdt.NewORSet[string]()
+	anon := itc.NewStamp(itc.NewId(0), itc.NewEvent(0))
+
+	s2, stamp := s.Add(anon, "x")
+	assert.True(s2 == s && stamp == anon, t)
+	assert.False(s2.Contains("x"), t)
+}
+
+func TestORSetAddWins(t *testing.T) {
+	a, _ := itc.SeedStamp().Fork()
+	s, a := crdt.NewORSet[string]().Add(a, "x")
+
+	// b sees the add and removes it while a adds it again concurrently
+	sb := crdt.NewORSet[string]().Merge(s).Remove("x")
+	sa, _ := s.Add(a, "x")
+
+	assert.True(sa.Merge(sb).Contains("x"), t)
+	assert.True(sb.Merge(sa).Contains("x"), t)
+
+	// Without the concurrent add the remove wins
+	assert.False(s.Merge(sb).Contains("x"), t)
+	assert.False(sb.Merge(s).Contains("x"), t)
+}
+
+// Replicas add, remove and merge at random. Merging everything in any order gives the same set.
+func TestORSetConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	stamps := []*itc.Stamp{itc.SeedStamp()}
+	for len(stamps) < 4 {
+		a, b := stamps[0].Fork()
+		stamps[0] = a
+		stamps = append(stamps, b)
+	}
+	sets := make([]*crdt.ORSet[int], len(stamps))
+	for i := range sets {
+		sets[i] = crdt.NewORSet[int]()
+	}
+
+	for step := 0; step < 300; step++ {
+		i := rng.Intn(len(sets))
+		switch rng.Intn(3) {
+		case 0:
+			sets[i], stamps[i] = sets[i].Add(stamps[i], rng.Intn(8))
+		case 1:
+			sets[i] = sets[i].Remove(rng.Intn(8))
+		default:
+			sets[i] = sets[i].Merge(sets[rng.Intn(len(sets))])
+		}
+	}
+
+	forward := sets[0]
+	backward := sets[len(sets)-1]
+	for i := 1; i < len(sets); i++ {
+		forward = forward.Merge(sets[i])
+		backward = backward.Merge(sets[len(sets)-1-i])
+	}
+	for e := 0; e < 8; e++ {
+		assert.True(forward.Contains(e) == backward.Contains(e), t)
+	}
+}
+
+func TestORSetCompact(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	sa, a := crdt.NewORSet[string]().Add(a, "x")
+	sb, b := crdt.NewORSet[string]().Add(b, "x")
+	m := sa.Merge(sb)
+	assert.True(len(m.Dots("x")) == 2, t)
+
+	tracker := itc.NewStabilityTracker("a", "b")
+	assert.Nil(tracker.Observe("a", m.Context()), t)
+	assert.True(len(m.Compact(tracker.Frontier()).Dots("x")) == 2, t)
+	assert.Nil(tracker.Observe("b", m.Context()), t)
+
+	c := m.Compact(tracker.Frontier())
+	assert.True(len(c.Dots("x")) == 1, t)
+
+	// Compacted and uncompacted replicas still agree
+	assert.True(c.Merge(m).Contains("x"), t)
+	assert.True(m.Merge(c).Contains("x"), t)
+	assert.False(c.Merge(m.Remove("x")).Contains("x"), t)
+	assert.False(m.Remove("x").Merge(c).Contains("x"), t)
+}
+
+func TestORSetEncode(t *testing.T) {
+	s, stamp := crdt.NewORSet[string]().Add(itc.SeedStamp(), "x")
+	s, _ = s.Add(stamp, "y")
+	s = s.Remove("x")
+
+	bytes, err := s.Encode()
+	assert.Nil(err, t)
+	decoded, err := crdt.DecodeORSet[string](bytes)
+	assert.Nil(err, t)
+	assert.True(decoded.Contains("y"), t)
+	assert.False(decoded.Contains("x"), t)
+	assert.True(decoded.Context().Leq(s.Context()) && s.Context().Leq(decoded.Context()), t)
+
+	// The decoded set still knows that x was removed
+	old, _ := crdt.NewORSet[string]().Add(itc.SeedStamp(), "x")
+	assert.False(decoded.Merge(old).Contains("x"), t)
+}
+
+func TestORSetEncodeStable(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	sa, sb := crdt.NewORSet[string](), crdt.NewORSet[string]()
+	for i := 0; i < 16; i++ {
+		sa, a = sa.Add(a, fmt.Sprintf("a%d", i))
+		sb, b = sb.Add(b, fmt.Sprintf("b%d", i))
+	}
+	sb, _ = sb.Add(b, "a0")
+
+	// Equal sets built in different orders encode to the same bytes, every time
+	first, err := sa.Merge(sb).Encode()
+	assert.Nil(err, t)
+	for i := 0; i < 8; i++ {
+		again, err := sb.Merge(sa).Encode()
+		assert.Nil(err, t)
+		assert.True(bytes.Equal(first, again), t)
+	}
+}
@@ -0,0 +1,140 @@
+package crdt_test
+
+import (
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/crdt"
+	"math/rand"
+	"testing"
+)
+
+func TestPNCounterForkJoin(t *testing.T) {
+	s := itc.SeedStamp()
+	c := crdt.NewPNCounter().Increment(s.Id, 5)
+
+	a, b := s.Fork()
+	ca := c.Increment(a.Id, 2)
+	cb := c.Increment(b.Id, 3).Decrement(b.Id, 1)
+	assert.True(ca.Value() == 7, t)
+	assert.True(cb.Value() == 7, t)
+	assert.True(ca.Merge(cb).Value() == 9, t)
+
+	// The joined replica counts against the seed's interval again, on top of what it had
+	s = a.Join(b)
+	c = ca.Merge(cb).Increment(s.Id, 1)
+	assert.True(c.Value() == 10, t)
+
+	// Stale replicas still merge to the right value
+	assert.True(ca.Merge(c).Value() == 10, t)
+	assert.True(c.Merge(cb).Merge(ca).Value() == 10, t)
+}
+
+func TestPNCounterAbsorb(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+
+	// Concurrent increments, b never hearing of a's
+	ca := crdt.NewPNCounter().Increment(a.Id, 3)
+	cb := crdt.NewPNCounter().Increment(b.Id, 2)
+
+	// a retires into b, which forks a new replica c and keeps counting against a's old interval
+	stale := cb
+	absorbed, id, err := cb.Absorb(b.Id, ca, a.Id)
+	assert.Nil(err, t)
+	assert.True(absorbed.Value() == 5, t)
+	b, c := itc.NewStamp(id, b.Event).Fork()
+	assert.True(b.Id.Overlaps(a.Id), t)
+
+	cc := absorbed.Increment(c.Id, 4)
+	cb = absorbed.Increment(b.Id, 1)
+
+	// Whoever counts against a's interval does so on top of a's increments, so no merge order loses any
+	assert.True(cc.Merge(ca).Merge(cb).Value() == 10, t)
+	assert.True(ca.Merge(cb).Merge(cc).Value() == 10, t)
+
+	// Taking the id without the counter counts the interval again from a stale view and loses increments
+	naiveB := stale.Increment(b.Id, 1)
+	naiveC := stale.Increment(c.Id, 4)
+	assert.True(naiveC.Merge(ca).Merge(naiveB).Value() == 9, t)
+
+	_, _, err = cb.Absorb(b.Id, cc, b.Id)
+	assert.True(err == itc.ErrOverlappingIds, t)
+}
+
+func TestPNCounterDefragment(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+	a := itc.NewStamp(&itc.Id{Left: zero, Right: &itc.Id{Left: one, Right: zero}}, itc.NewEvent(0))
+	b := itc.NewStamp(&itc.Id{Left: &itc.Id{Left: one, Right: zero}, Right: &itc.Id{Left: zero, Right: one}}, itc.NewEvent(0))
+	ca := crdt.NewPNCounter().Increment(a.Id, 3)
+	cb := crdt.NewPNCounter().Increment(b.Id, 2)
+
+	// a is handed the interval b was counting against
+	da, db, err := itc.Defragment(a, b)
+	assert.Nil(err, t)
+	assert.True(da.Id.Overlaps(b.Id), t)
+
+	// Counting against it from a's own view loses one of b's increments
+	naive := ca.Increment(da.Id, 1)
+	assert.True(naive.Merge(cb).Value() == 5, t)
+
+	absorbedA, _, err := ca.Absorb(a.Id, cb, b.Id)
+	assert.Nil(err, t)
+	absorbedB, _, err := cb.Absorb(b.Id, ca, a.Id)
+	assert.Nil(err, t)
+	ca = absorbedA.Increment(da.Id, 1)
+	cb = absorbedB.Increment(db.Id, 1)
+	assert.True(ca.Merge(cb).Value() == 7, t)
+	assert.True(cb.Merge(ca).Value() == 7, t)
+}
+
+// Replicas fork, join, count and sync at random. The merged counter always matches the true total.
+func TestPNCounterRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	stamps := []*itc.Stamp{itc.SeedStamp()}
+	counters := []*crdt.PNCounter{crdt.NewPNCounter()}
+	var total int64
+
+	for step := 0; step < 500; step++ {
+		i := rng.Intn(len(stamps))
+		switch r := rng.Intn(10); {
+		case r < 2 && len(stamps) < 8:
+			a, b := stamps[i].Fork()
+			stamps[i] = a
+			stamps = append(stamps, b)
+			counters = append(counters, counters[i])
+		case r < 4 && len(stamps) > 1:
+			j := (i + 1 + rng.Intn(len(stamps)-1)) % len(stamps)
+			stamps[i] = stamps[i].Join(stamps[j])
+			counters[i] = counters[i].Merge(counters[j])
+			stamps = append(stamps[:j], stamps[j+1:]...)
+			counters = append(counters[:j], counters[j+1:]...)
+		case r < 7:
+			n := uint64(rng.Intn(5))
+			counters[i] = counters[i].Increment(stamps[i].Id, n)
+			total += int64(n)
+		case r < 8:
+			n := uint64(rng.Intn(5))
+			counters[i] = counters[i].Decrement(stamps[i].Id, n)
+			total -= int64(n)
+		default:
+			counters[i] = counters[i].Merge(counters[rng.Intn(len(counters))])
+		}
+
+		merged := crdt.NewPNCounter()
+		for _, c := range counters {
+			merged = merged.Merge(c)
+		}
+		assert.True(merged.Value() == total, t)
+	}
+}
+
+func TestPNCounterEncode(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	c := crdt.NewPNCounter().Increment(a.Id, 4).Decrement(b.Id, 1)
+
+	bytes, err := c.Encode()
+	assert.Nil(err, t)
+	decoded, err := crdt.DecodePNCounter(bytes)
+	assert.Nil(err, t)
+	assert.True(decoded.Value() == 3, t)
+	assert.True(decoded.Merge(c).Value() == 3, t)
+}
@@ -0,0 +1,120 @@
+package crdt_test
+
+import (
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/crdt"
+	"sort"
+	"testing"
+)
+
+func sorted(values []string) []string {
+	sort.Strings(values)
+	return values
+}
+
+func TestMVRegisterConcurrent(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	r := crdt.NewMVRegister[string]()
+	assert.True(len(r.Values()) == 0, t)
+
+	ra, a := r.Set(a, "a")
+	rb, b := r.Set(b, "b")
+
+	m := ra.Merge(rb)
+	assert.True(len(m.Values()) == 2, t)
+	assert.True(sorted(m.Values())[0] == "a", t)
+
+	// A write that has seen both values replaces them
+	m, a = m.Set(a, "ab")
+	assert.True(len(m.Values()) == 1 && m.Values()[0] == "ab", t)
+	assert.True(len(rb.Merge(m).Values()) == 1, t)
+
+	// b writes without seeing the merge and conflicts again
+	rb, _ = rb.Set(b, "b2")
+	assert.True(len(m.Merge(rb).Values()) == 2, t)
+}
+
+func TestMVRegisterEncode(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	ra, _ := crdt.NewMVRegister[string]().Set(a, "a")
+	rb, _ := crdt.NewMVRegister[string]().Set(b, "b")
+	m := ra.Merge(rb)
+
+	bytes, err := m.Encode()
+	assert.Nil(err, t)
+	decoded, err := crdt.DecodeMVRegister[string](bytes)
+	assert.Nil(err, t)
+	assert.True(len(decoded.Values()) == 2, t)
+	assert.True(len(decoded.Merge(m).Values()) == 2, t)
+
+	_, err = crdt.DecodeMVRegister[string](bytes[:len(bytes)/2])
+	assert.Err(err, t)
+}
+
+func TestLWWRegisterCausal(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	r := crdt.NewLWWRegister[int]()
+	_, ok := r.Get()
+	assert.False(ok, t)
+
+	r1, _ := r.Set(a, 1)
+	// b has seen the first write so its write wins no matter who has the larger Id
+	r2, _ := r1.Set(b, 2)
+
+	v, ok := r1.Merge(r2).Get()
+	assert.True(ok && v == 2, t)
+	v, _ = r2.Merge(r1).Get()
+	assert.True(v == 2, t)
+}
+
+func TestLWWRegisterConcurrent(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	r := crdt.NewLWWRegister[int]()
+
+	ra, a := r.Set(a, 1)
+	rb, _ := r.Set(b, 2)
+
+	// Both orders agree on the winner
+	v1, _ := ra.Merge(rb).Get()
+	v2, _ := rb.Merge(ra).Get()
+	assert.True(v1 == v2, t)
+
+	// The next write after the merge wins outright
+	m, _ := ra.Merge(rb).Set(a, 3)
+	v, _ := rb.Merge(m).Get()
+	assert.True(v == 3, t)
+}
+
+func TestRegisterSetAnonymous(t *testing.T) {
+	anon := itc.NewStamp(itc.NewId(0), itc.NewEvent(0))
+
+	lww := crdt.NewLWWRegister[string]()
+	lww2, stamp := lww.Set(anon, "x")
+	assert.True(lww2 == lww && stamp == anon, t)
+	_, ok := lww2.Get()
+	assert.False(ok, t)
+
+	mv := crdt.NewMVRegister[string]()
+	mv2, stamp := mv.Set(anon, "x")
+	assert.True(mv2 == mv && stamp == anon, t)
+	assert.True(len(mv2.Values()) == 0, t)
+}
+
+func TestLWWRegisterEncode(t *testing.T) {
+	r, _ := crdt.NewLWWRegister[string]().Set(itc.SeedStamp(), "x")
+
+	bytes, err := r.Encode()
+	assert.Nil(err, t)
+	decoded, err := crdt.DecodeLWWRegister[string](bytes)
+	assert.Nil(err, t)
+	v, ok := decoded.Get()
+	assert.True(ok && v == "x", t)
+
+	empty, err := crdt.NewLWWRegister[string]().Encode()
+	assert.Nil(err, t)
+	decoded, err = crdt.DecodeLWWRegister[string](empty)
+	assert.Nil(err, t)
+	_, ok = decoded.Get()
+	assert.False(ok, t)
+}
@@ -0,0 +1,187 @@
+package recovery_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/recovery"
+	"testing"
+)
+
+// A voter that cannot be reached
+type down struct {
+	*recovery.Voter
+}
+
+func (d down) Prepare(uint64) (recovery.Promise, error) {
+	return recovery.Promise{}, errors.New("unreachable")
+}
+
+func (d down) Accept(recovery.Proposal) error {
+	return errors.New("unreachable")
+}
+
+// Stamps for n replicas forked from the seed
+func forked(n int) []*itc.Stamp {
+	stamps := []*itc.Stamp{itc.SeedStamp()}
+	for len(stamps) < n {
+		a, b := stamps[0].Fork()
+		stamps = append(stamps[1:], a, b)
+	}
+	return stamps
+}
+
+// Voters for the stamps' replicas
+func votersFor(stamps []*itc.Stamp) []*recovery.Voter {
+	vs := make([]*recovery.Voter, len(stamps))
+	for i, s := range stamps {
+		vs[i] = recovery.NewVoter(fmt.Sprintf("r%d", i), s.Id)
+	}
+	return vs
+}
+
+// Voters for n replicas forked from the seed
+func voters(n int) []*recovery.Voter {
+	return votersFor(forked(n))
+}
+
+func acceptors(vs []*recovery.Voter, downs ...int) []recovery.Acceptor {
+	as := make([]recovery.Acceptor, len(vs))
+	for i, v := range vs {
+		as[i] = v
+	}
+	for _, i := range downs {
+		as[i] = down{vs[i]}
+	}
+	return as
+}
+
+func TestRecover(t *testing.T) {
+	vs := voters(4)
+	lost := vs[2].Id()
+
+	p, err := recovery.Recover(1, acceptors(vs, 2), "r0")
+	assert.Nil(err, t)
+	assert.True(proto.Equal(p.Orphan, lost), t, p.Orphan.Print())
+	assert.True(len(p.Fenced) == 1 && p.Fenced[0] == "r2", t)
+
+	// The grant is held until the fenced replica can no longer be recording
+	assert.True(proto.Equal(vs[0].Pending(), lost), t)
+	assert.False(vs[0].Id().Overlaps(lost), t)
+	vs[0].Expire()
+	assert.True(vs[0].Pending() == nil, t)
+
+	// The survivor owns the lost region and the live ids sum to the seed again
+	assert.False(vs[0].Id().Overlaps(vs[1].Id()), t)
+	assert.False(vs[0].Id().Overlaps(vs[3].Id()), t)
+	sum := vs[0].Id().Sum(vs[1].Id()).Sum(vs[3].Id())
+	assert.True(proto.Equal(sum, itc.NewId(1)), t, sum.Print())
+
+	for _, i := range []int{0, 1, 3} {
+		assert.True(vs[i].Epoch() == 1, t)
+		assert.True(vs[i].Admit("r2", 1) == recovery.ErrFenced, t)
+	}
+}
+
+func TestRecoverFalseSuspicion(t *testing.T) {
+	vs := voters(4)
+	p, err := recovery.Recover(1, acceptors(vs, 2), "r0")
+	assert.Nil(err, t)
+
+	// The suspected replica was alive all along. Peers refuse it even before it learns of the recovery, and a peer
+	// that missed the recovery is caught by its stale epoch.
+	assert.True(vs[1].Admit("r2", vs[2].Epoch()) == recovery.ErrFenced, t)
+	assert.True(vs[2].Epoch() == 0, t)
+
+	vs[2].Learn(p)
+	assert.True(vs[2].Fenced(), t)
+	assert.True(vs[2].Id().IsEmpty(), t)
+	assert.True(vs[2].SetId(itc.NewId(1)) == recovery.ErrFenced, t)
+
+	_, err = vs[2].Prepare(2)
+	assert.True(err == recovery.ErrFenced, t)
+}
+
+func TestRecoverLiveMinority(t *testing.T) {
+	stamps := forked(4)
+	vs := votersFor(stamps)
+
+	// r2 is cut off, never sees the proposal and keeps recording events
+	p, err := recovery.Recover(1, acceptors(vs, 2), "r0")
+	assert.Nil(err, t)
+	stamps[2] = stamps[2].Advance().Advance()
+	assert.True(proto.Equal(vs[2].Id(), stamps[2].Id), t)
+	assert.False(vs[0].Id().Overlaps(vs[2].Id()), t)
+
+	// A new recovery while the grant is held still counts the region as owned
+	_, err = recovery.Recover(2, acceptors(vs, 2), "r1")
+	assert.Nil(err, t)
+	assert.True(vs[1].Pending() == nil, t)
+	assert.False(vs[0].Id().Overlaps(vs[2].Id()), t)
+
+	// The partition heals: r2 learns it was fenced and hands over its events before the survivor takes the region
+	vs[2].Learn(p)
+	assert.True(vs[2].Id().IsEmpty(), t)
+	assert.True(vs[0].Acknowledge("r2", 2) == recovery.ErrStaleEpoch, t)
+	survivor := itc.NewStamp(stamps[0].Id, stamps[0].Event.Join(stamps[2].Event))
+	assert.Nil(vs[0].Acknowledge("r2", 1), t)
+	assert.True(vs[0].Pending() == nil, t)
+	assert.True(vs[0].Id().Overlaps(stamps[2].Id), t)
+
+	// Events the survivor records in the region come after those of r2
+	survivor = itc.NewStamp(vs[0].Id(), survivor.Event).Advance()
+	assert.True(stamps[2].Leq(survivor), t)
+	assert.False(survivor.Leq(stamps[2]), t)
+	sum := vs[0].Id().Sum(vs[1].Id()).Sum(vs[3].Id())
+	assert.True(proto.Equal(sum, itc.NewId(1)), t, sum.Print())
+}
+
+func TestRecoverNoQuorum(t *testing.T) {
+	vs := voters(4)
+	before := vs[0].Id()
+
+	_, err := recovery.Recover(1, acceptors(vs, 2, 3), "r0")
+	assert.True(err == recovery.ErrNoQuorum, t)
+	assert.True(proto.Equal(vs[0].Id(), before), t)
+	assert.Nil(vs[0].Admit("r2", 0), t)
+}
+
+func TestRecoverStaleEpoch(t *testing.T) {
+	vs := voters(3)
+	_, err := recovery.Recover(2, acceptors(vs, 2), "r0")
+	assert.Nil(err, t)
+
+	_, err = recovery.Recover(1, acceptors(vs), "r1")
+	assert.True(err == recovery.ErrStaleEpoch, t)
+}
+
+func TestRecoverUnknownSurvivor(t *testing.T) {
+	vs := voters(3)
+	_, err := recovery.Recover(1, acceptors(vs, 2), "r2")
+	assert.True(err == recovery.ErrUnknownSurvivor, t)
+}
+
+// A voter that forks between its promise and the proposal
+type forking struct {
+	*recovery.Voter
+}
+
+func (f forking) Prepare(epoch uint64) (recovery.Promise, error) {
+	p, err := f.Voter.Prepare(epoch)
+	a, _ := f.Id().Split()
+	f.SetId(a)
+	return p, err
+}
+
+func TestRecoverIdChanged(t *testing.T) {
+	vs := voters(3)
+	as := acceptors(vs, 2)
+	as[0] = forking{vs[0]}
+
+	// The survivor's new fork would be counted as orphaned, so the grant is refused
+	_, err := recovery.Recover(1, as, "r0")
+	assert.True(err == recovery.ErrIdChanged, t)
+	assert.False(vs[0].Id().Overlaps(vs[2].Id()), t)
+}
@@ -0,0 +1,108 @@
+package sync_test
+
+import (
+	"errors"
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/sync"
+	"net"
+	"testing"
+)
+
+type accepted struct {
+	stamp    *itc.Stamp
+	absorbed sync.Absorbed
+	err      error
+}
+
+// Run a retirement over net.Pipe, returning the donor's outcome and the leaving replica's error
+func retire(r sync.Retirement, donor *itc.Stamp, absorbed sync.Absorbed,
+	commit func(*itc.Stamp, sync.Absorbed) error) (accepted, error) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan accepted, 1)
+	go func() {
+		s, abs, err := sync.AcceptRetirement(b, donor, absorbed, commit, sync.Options{})
+		if err != nil {
+			b.Close()
+		}
+		done <- accepted{s, abs, err}
+	}()
+
+	err := sync.Retire(a, r, sync.Options{})
+	if err != nil {
+		a.Close()
+	}
+	return <-done, err
+}
+
+func TestRetire(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	b = b.Advance()
+
+	r, err := sync.NewRetirement(b)
+	assert.Nil(err, t)
+	assert.True(len(r.Token) == 32, t)
+
+	d, err := retire(r, a, nil, nil)
+	assert.Nil(err, t)
+	assert.Nil(d.err, t)
+
+	// The donor owns the whole id space again and has seen the leaving replica's events
+	assert.True(proto.Equal(d.stamp.Id, itc.NewId(1)), t, d.stamp.Id.Print())
+	assert.True(b.Leq(d.stamp), t)
+	assert.True(d.absorbed[r.Token], t)
+}
+
+func TestRetireRetry(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	r, _ := sync.NewRetirement(b.Advance())
+
+	donor, absorbed, err := sync.Absorb(a, nil, r)
+	assert.Nil(err, t)
+
+	// The donor forks away the absorbed share, then the leaving replica retries after losing the acknowledgement
+	donor, _ = donor.Fork()
+	again, absorbed, err := sync.Absorb(donor, absorbed, r)
+	assert.Nil(err, t)
+	assert.True(proto.Equal(again.Id, donor.Id), t)
+	assert.True(len(absorbed) == 1, t)
+}
+
+func TestRetireOverlap(t *testing.T) {
+	a, _ := itc.SeedStamp().Fork()
+	r, _ := sync.NewRetirement(a)
+
+	d, err := retire(r, a, nil, nil)
+	assert.True(err == sync.ErrOverlap, t)
+	assert.True(d.err == sync.ErrOverlap, t)
+	assert.True(proto.Equal(d.stamp, a), t)
+	assert.True(len(d.absorbed) == 0, t)
+}
+
+func TestRetireCommitFailure(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	r, _ := sync.NewRetirement(b)
+
+	// The donor crashes before persisting, so the leaving replica is not acknowledged
+	d, err := retire(r, a, nil, func(*itc.Stamp, sync.Absorbed) error {
+		return errors.New("disk full")
+	})
+	assert.Err(err, t)
+	assert.Err(d.err, t)
+	assert.True(proto.Equal(d.stamp, a), t)
+
+	// The retry succeeds
+	var saved *itc.Stamp
+	d, err = retire(r, a, nil, func(s *itc.Stamp, _ sync.Absorbed) error {
+		saved = s
+		return nil
+	})
+	assert.Nil(err, t)
+	assert.Nil(d.err, t)
+	assert.True(proto.Equal(saved, d.stamp), t)
+	assert.True(proto.Equal(d.stamp.Id, itc.NewId(1)), t)
+}
@@ -0,0 +1,343 @@
+package sync_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/clock"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/itc/sync"
+	"net"
+	"testing"
+)
+
+type outcome struct {
+	result sync.Result
+	err    error
+}
+
+// Run a session over net.Pipe, returning the initiator's and responder's outcomes
+func session(initiator, responder *itc.Stamp, options sync.Options) (outcome, outcome) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan outcome, 1)
+	go func() {
+		r, _, err := sync.Respond(b, responder, nil, nil, options)
+		if err != nil {
+			b.Close()
+		}
+		done <- outcome{r, err}
+	}()
+
+	r, err := sync.Initiate(a, initiator, options)
+	if err != nil {
+		a.Close()
+	}
+	return outcome{r, err}, <-done
+}
+
+func TestSessionInitiatorBehind(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	b = b.Advance().Advance()
+
+	i, r := session(a, b, sync.Options{})
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+
+	assert.True(i.result.Ordering == clock.Before, t, i.result.Ordering.String())
+	assert.True(r.result.Ordering == clock.After, t, r.result.Ordering.String())
+
+	// Ids are untouched and both sides end with the joined history
+	assert.True(proto.Equal(i.result.Stamp.Id, a.Id), t)
+	assert.True(proto.Equal(r.result.Stamp.Id, b.Id), t)
+	assert.True(proto.Equal(i.result.Stamp.Event, b.Event), t)
+	assert.True(proto.Equal(r.result.Stamp.Event, b.Event), t)
+}
+
+func TestSessionResponderBehind(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	a = a.Advance()
+
+	i, r := session(a, b, sync.Options{})
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+
+	assert.True(i.result.Ordering == clock.After, t)
+	assert.True(r.result.Ordering == clock.Before, t)
+	assert.True(proto.Equal(r.result.Stamp.Event, a.Event), t)
+}
+
+func TestSessionConcurrent(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	a = a.Advance()
+	b = b.Advance().Advance()
+
+	i, r := session(a, b, sync.Options{})
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+
+	assert.True(i.result.Ordering == clock.Concurrent, t)
+	assert.True(r.result.Ordering == clock.Concurrent, t)
+
+	joined := a.Event.Join(b.Event)
+	assert.True(proto.Equal(i.result.Stamp.Event, joined), t)
+	assert.True(proto.Equal(r.result.Stamp.Event, joined), t)
+}
+
+func TestSessionDeltaIsSmaller(t *testing.T) {
+	stamps := []*itc.Stamp{itc.SeedStamp()}
+	for len(stamps) < 16 {
+		a, b := stamps[0].Fork()
+		stamps = append(stamps[1:], a, b)
+	}
+	history := itc.NewEvent(0)
+	for k, s := range stamps {
+		for n := 0; n <= k; n++ {
+			s = s.Advance()
+		}
+		history = history.Join(s.Event)
+	}
+
+	// Both sides share a history spread over many replicas, the responder has one event more
+	a := itc.NewStamp(stamps[0].Id, history)
+	b := itc.NewStamp(stamps[1].Id, history).Advance()
+
+	full, err := proto.Marshal(b.Event)
+	assert.Nil(err, t)
+
+	i, r := session(a, b, sync.Options{})
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+	assert.True(proto.Equal(i.result.Stamp.Event, b.Event), t)
+
+	// The reply carries only the delta, so it is smaller than the full tree even with its framing
+	assert.True(i.result.Received < len(full), t)
+	assert.True(i.result.Sent == r.result.Received, t)
+	assert.True(i.result.Received == r.result.Sent, t)
+}
+
+func TestSessionFork(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	b, c := b.Fork()
+	a = a.Advance()
+	c = c.Advance()
+
+	i, r := session(c, a, sync.Options{Fork: true, Token: "t"})
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+
+	// The new ids are disjoint and cover what the two held before
+	ic, ia := i.result.Stamp.Id, r.result.Stamp.Id
+	assert.False(ic.Overlaps(ia), t)
+	assert.False(ic.IsEmpty(), t)
+	assert.False(ia.IsEmpty(), t)
+	assert.True(proto.Equal(ic.Sum(ia), a.Id.Sum(c.Id)), t)
+	assert.False(ic.Overlaps(b.Id), t)
+	assert.False(ia.Overlaps(b.Id), t)
+
+	assert.True(proto.Equal(i.result.Stamp.Event, r.result.Stamp.Event), t)
+	assert.True(proto.Equal(i.result.Stamp.Event, a.Event.Join(c.Event)), t)
+}
+
+// Closes the connection once it has written the given number of frames, losing whatever the peer sends next
+type dropAfter struct {
+	net.Conn
+	writes int
+}
+
+func (d *dropAfter) Write(b []byte) (int, error) {
+	n, err := d.Conn.Write(b)
+	if d.writes--; d.writes == 0 {
+		d.Conn.Close()
+	}
+	return n, err
+}
+
+type responded struct {
+	outcome
+	exchanged sync.Exchanged
+}
+
+// Run an exchange over net.Pipe where the initiator drops the connection after the given number of frames, 0 to keep it
+func exchange(initiator, responder *itc.Stamp, exchanged sync.Exchanged, drop int,
+	options sync.Options) (outcome, responded) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan responded, 1)
+	go func() {
+		r, ex, err := sync.Respond(b, responder, exchanged, nil, options)
+		if err != nil {
+			b.Close()
+		}
+		done <- responded{outcome{r, err}, ex}
+	}()
+
+	var rw net.Conn = a
+	if drop > 0 {
+		rw = &dropAfter{Conn: a, writes: drop}
+	}
+	r, err := sync.Initiate(rw, initiator, options)
+	if err != nil {
+		a.Close()
+	}
+	return outcome{r, err}, <-done
+}
+
+func TestSessionForkRetry(t *testing.T) {
+	a, c := itc.SeedStamp().Fork()
+	a = a.Advance()
+	token, err := sync.NewToken()
+	assert.Nil(err, t)
+	options := sync.Options{Fork: true, Token: token}
+
+	// The acknowledgement of the commit is lost: the responder has its new Id, the initiator is in doubt
+	i, r := exchange(c, a, nil, 2, options)
+	assert.True(errors.Is(i.err, sync.ErrInDoubt), t)
+	assert.Err(r.err, t)
+	assert.True(r.result.Stamp != nil, t)
+	assert.True(len(r.exchanged) == 1, t)
+	responder := r.result.Stamp
+	assert.True(responder.Id.Overlaps(c.Id), t)
+
+	// The responder moves on, then the initiator retries with the same token and is handed the recorded Id
+	responder, _ = responder.Advance().Fork()
+	i, r = exchange(c, responder, r.exchanged, 0, options)
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+	assert.True(proto.Equal(r.result.Stamp.Id, responder.Id), t)
+	assert.False(i.result.Stamp.Id.Overlaps(responder.Id), t)
+	assert.True(proto.Equal(i.result.Stamp.Id, r.exchanged[token].Handed), t)
+	assert.True(responder.Leq(i.result.Stamp), t)
+}
+
+func TestSessionForkTokenReused(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	b, c := b.Fork()
+	options := sync.Options{Fork: true, Token: "t"}
+
+	i, r := exchange(c, a, nil, 0, options)
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+
+	// Another initiator presenting the same token is not handed the recorded Id
+	responder := r.result.Stamp
+	i, r = exchange(b, responder, r.exchanged, 0, options)
+	assert.True(i.err == sync.ErrTokenReused, t)
+	assert.True(r.err == sync.ErrTokenReused, t)
+	assert.True(r.result.Stamp == nil, t)
+}
+
+func TestSessionForkCommitLost(t *testing.T) {
+	a, c := itc.SeedStamp().Fork()
+	options := sync.Options{Fork: true, Token: "t"}
+
+	// The commit never reaches the responder, so neither side changes its Id and a retry starts afresh
+	i, r := exchange(c, a, nil, 1, options)
+	assert.True(i.err != nil && !errors.Is(i.err, sync.ErrInDoubt), t)
+	assert.Err(r.err, t)
+	assert.True(len(r.exchanged) == 0, t)
+
+	i, r = exchange(c, a, r.exchanged, 0, options)
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+	assert.False(i.result.Stamp.Id.Overlaps(r.result.Stamp.Id), t)
+	assert.True(proto.Equal(i.result.Stamp.Id.Sum(r.result.Stamp.Id), itc.NewId(1)), t)
+}
+
+func TestSessionForkNoToken(t *testing.T) {
+	_, err := sync.Initiate(&bytes.Buffer{}, itc.SeedStamp(), sync.Options{Fork: true})
+	assert.True(err == sync.ErrNoToken, t)
+}
+
+func TestSessionDefragment(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+	a := itc.NewStamp(&itc.Id{Left: one, Right: &itc.Id{Left: zero, Right: one}}, itc.NewEvent(0)).Advance()
+	b := itc.NewStamp(&itc.Id{Left: zero, Right: &itc.Id{Left: one, Right: zero}}, itc.NewEvent(0)).Advance()
+
+	i, r := session(a, b, sync.Options{Defragment: true, Token: "t"})
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+
+	expected, _, err := itc.Defragment(a, b)
+	assert.Nil(err, t)
+	assert.True(proto.Equal(i.result.Stamp.Id, expected.Id), t, i.result.Stamp.Id.Print())
+	assert.True(i.result.Stamp.Id.Fragments() == 1, t)
+	assert.True(r.result.Stamp.Id.Fragments() == 1, t)
+	assert.True(proto.Equal(i.result.Stamp.Event, r.result.Stamp.Event), t)
+}
+
+func TestSessionDefragmentRetry(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+	a := itc.NewStamp(&itc.Id{Left: one, Right: &itc.Id{Left: zero, Right: one}}, itc.NewEvent(0)).Advance()
+	b := itc.NewStamp(&itc.Id{Left: zero, Right: &itc.Id{Left: one, Right: zero}}, itc.NewEvent(0)).Advance()
+	options := sync.Options{Defragment: true, Token: "t"}
+
+	// The responder takes its contiguous share, the initiator loses the acknowledgement and keeps its old Id
+	i, r := exchange(a, b, nil, 2, options)
+	assert.True(errors.Is(i.err, sync.ErrInDoubt), t)
+	responder := r.result.Stamp
+	assert.True(responder.Id.Fragments() == 1, t)
+	assert.True(responder.Id.Overlaps(a.Id), t)
+
+	// Without the record the retry would look like an overlap
+	i, _ = exchange(a, responder, nil, 0, options)
+	assert.True(i.err == sync.ErrOverlap, t)
+
+	i, r = exchange(a, responder, r.exchanged, 0, options)
+	assert.Nil(i.err, t)
+	assert.Nil(r.err, t)
+	expected, _, err := itc.Defragment(a, b)
+	assert.Nil(err, t)
+	assert.True(proto.Equal(i.result.Stamp.Id, expected.Id), t, i.result.Stamp.Id.Print())
+	assert.True(proto.Equal(r.result.Stamp.Id, responder.Id), t)
+	assert.False(i.result.Stamp.Id.Overlaps(r.result.Stamp.Id), t)
+}
+
+func TestSessionForkOverlap(t *testing.T) {
+	a, _ := itc.SeedStamp().Fork()
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	// Neither side closes the connection, the initiator learns of the refusal from the reply
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := sync.Respond(c2, a, nil, nil, sync.Options{Fork: true, Token: "t"})
+		done <- err
+	}()
+	_, err := sync.Initiate(c1, a, sync.Options{Fork: true, Token: "t"})
+	assert.True(err == sync.ErrOverlap, t)
+	assert.True(<-done == sync.ErrOverlap, t)
+
+	// The connection is still usable
+	go func() {
+		_, _, err := sync.Respond(c2, a, nil, nil, sync.Options{})
+		done <- err
+	}()
+	_, err = sync.Initiate(c1, a, sync.Options{})
+	assert.Nil(err, t)
+	assert.Nil(<-done, t)
+}
+
+func TestSessionFrameTooLarge(t *testing.T) {
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint32(1<<20))
+
+	_, _, err := sync.Respond(&b, itc.SeedStamp(), nil, nil, sync.Options{MaxFrame: 1 << 10})
+	assert.True(err == sync.ErrFrameTooLarge, t)
+}
+
+func TestSessionMalformed(t *testing.T) {
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint32(3))
+	b.Write([]byte{1, 0, 5})
+
+	_, _, err := sync.Respond(&b, itc.SeedStamp(), nil, nil, sync.Options{})
+	assert.True(err == sync.ErrMalformed, t)
+}
@@ -0,0 +1,60 @@
+package vector
+
+import "github.com/ziglet.io/go-itc/clock"
+
+// Adapt a vector clock to the LogicalClock interface
+func AsLogicalClock(vc *VectorClock) clock.LogicalClock {
+    return logicalClock{vc}
+}
+
+// Unwrap a LogicalClock produced by AsLogicalClock
+func FromLogicalClock(c clock.LogicalClock) (*VectorClock, bool) {
+    l, ok := c.(logicalClock)
+    return l.vc, ok
+}
+
+type logicalClock struct {
+    vc *VectorClock
+}
+
+func (l logicalClock) Tick() clock.LogicalClock {
+    return logicalClock{l.vc.Tick()}
+}
+
+// Merge the entries without ticking
+func (l logicalClock) Merge(other clock.LogicalClock) (clock.LogicalClock, error) {
+    o, ok := other.(logicalClock)
+    if !ok {
+        return nil, clock.ErrMismatch
+    }
+    return logicalClock{&VectorClock{
+        Owner:   l.vc.Owner,
+        Forks:   l.vc.Forks,
+        Entries: l.vc.Entries.Merge(o.vc.Entries),
+    }}, nil
+}
+
+func (l logicalClock) Compare(other clock.LogicalClock) (clock.Ordering, error) {
+    o, ok := other.(logicalClock)
+    if !ok {
+        return clock.Concurrent, clock.ErrMismatch
+    }
+    return clock.FromLeq(l.vc.Leq(o.vc), o.vc.Leq(l.vc)), nil
+}
+
+func (l logicalClock) Spawn() (clock.LogicalClock, clock.LogicalClock) {
+    a, b := l.vc.Fork()
+    return logicalClock{a}, logicalClock{b}
+}
+
+func (l logicalClock) Retire(into clock.LogicalClock) (clock.LogicalClock, error) {
+    o, ok := into.(logicalClock)
+    if !ok {
+        return nil, clock.ErrMismatch
+    }
+    return logicalClock{o.vc.Join(l.vc)}, nil
+}
+
+func (l logicalClock) Encode() ([]byte, error) {
+    return l.vc.Encode(), nil
+}
@@ -0,0 +1,88 @@
+package vector
+
+import (
+    "fmt"
+    "github.com/ziglet.io/go-itc/itc"
+    "math"
+    "sort"
+)
+
+// Conversion between version vectors and ITC events given an assignment of Ids to replicas.
+//
+// The Ids must be pairwise disjoint and none may be empty. Under that assignment the conversion is exact:
+//
+//  FromEvent(ToEvent(vv, ids), ids) equals vv for every vv over the replicas in ids
+//  vv1.Leq(vv2) if and only if ToEvent(vv1, ids).Leq(ToEvent(vv2, ids))
+//
+// Going the other way an ITC event can carry more detail than a version vector, since a replica may have
+// advanced only part of its region. FromEvent takes the minimum over each region, so the projection never claims
+// an update that was not seen:
+//
+//  ToEvent(FromEvent(e, ids), ids).Leq(e) for every event e
+//
+// with equality when e is constant over each replica's region and 0 outside all of them.
+
+// Build the event in which every point owned by a replica carries that replica's counter
+func ToEvent(vv VersionVector, ids map[string]*itc.Id) (*itc.Event, error) {
+    if err := checkIds(ids); err != nil {
+        return nil, err
+    }
+
+    event := itc.NewEvent(0)
+    for _, r := range vv.replicas() {
+        n := vv[r]
+        if n == 0 {
+            continue
+        }
+        id, ok := ids[r]
+        if !ok {
+            return nil, fmt.Errorf("vector: no id assigned to replica %q", r)
+        }
+        if n > math.MaxUint32 {
+            return nil, fmt.Errorf("vector: counter %d of replica %q does not fit an event", n, r)
+        }
+        event = event.Join(itc.EventOver(id, uint32(n)))
+    }
+
+    return event, nil
+}
+
+// Project the event onto a version vector over the replicas in ids. Zero counters are left out.
+func FromEvent(event *itc.Event, ids map[string]*itc.Id) (VersionVector, error) {
+    if err := checkIds(ids); err != nil {
+        return nil, err
+    }
+
+    vv := NewVersionVector()
+    for r, id := range ids {
+        n, _ := event.MinOver(id)
+        if n != 0 {
+            vv[r] = uint64(n)
+        }
+    }
+
+    return vv, nil
+}
+
+func checkIds(ids map[string]*itc.Id) error {
+    names := make([]string, 0, len(ids))
+    for r := range ids {
+        names = append(names, r)
+    }
+    sort.Strings(names)
+
+    seen := itc.NewId(0)
+    for _, r := range names {
+        id := ids[r]
+        if id.IsEmpty() {
+            return fmt.Errorf("vector: replica %q has an empty id", r)
+        }
+        if id.Overlaps(seen) {
+            return fmt.Errorf("vector: id of replica %q overlaps another replica", r)
+        }
+        // Sum expects normalised ids
+        seen = seen.Sum(id.NormDeep())
+    }
+
+    return nil
+}
@@ -0,0 +1,101 @@
+package vector
+
+import "strconv"
+
+// A vector clock in the style of Fidge and Mattern. Each process owns one entry that it ticks on every local event
+// and on every receive. New processes are named after their parent so that forking needs no coordination.
+type VectorClock struct {
+    Owner   string
+    Forks   uint64
+    Entries VersionVector
+}
+
+// Define the seed clock for a single named process
+func SeedClock(owner string) *VectorClock {
+    return &VectorClock{
+        Owner:   owner,
+        Entries: NewVersionVector(),
+    }
+}
+
+// Record a local event
+func (vc *VectorClock) Tick() *VectorClock {
+    return &VectorClock{
+        Owner:   vc.Owner,
+        Forks:   vc.Forks,
+        Entries: vc.Entries.Increment(vc.Owner),
+    }
+}
+
+// Spawn a new process. The parent keeps its name and the child is named "<owner>.<n>" where n counts the forks
+// the parent has made so far.
+func (vc *VectorClock) Fork() (*VectorClock, *VectorClock) {
+    parent := &VectorClock{
+        Owner:   vc.Owner,
+        Forks:   vc.Forks + 1,
+        Entries: vc.Entries.Copy(),
+    }
+    child := &VectorClock{
+        Owner:   vc.Owner + "." + strconv.FormatUint(vc.Forks, 10),
+        Entries: vc.Entries.Copy(),
+    }
+    return parent, child
+}
+
+// Receive the clock of another process: merge the entries and tick
+func (vc *VectorClock) Receive(other *VectorClock) *VectorClock {
+    c := &VectorClock{
+        Owner:   vc.Owner,
+        Forks:   vc.Forks,
+        Entries: vc.Entries.Merge(other.Entries),
+    }
+    return c.Tick()
+}
+
+// Join retires the other process into this one. The retired entry stays in the vector.
+func (vc *VectorClock) Join(other *VectorClock) *VectorClock {
+    return vc.Receive(other)
+}
+
+// Less or equals - the partial order on the entries
+func (vc *VectorClock) Leq(other *VectorClock) bool {
+    return vc.Entries.Leq(other.Entries)
+}
+
+// Encode the owner, fork count and entries
+func (vc *VectorClock) Encode() []byte {
+    b := appendUvarint(nil, uint64(len(vc.Owner)))
+    b = append(b, vc.Owner...)
+    b = appendUvarint(b, vc.Forks)
+    return appendEntries(b, vc.Entries)
+}
+
+func DecodeVectorClock(b []byte) (*VectorClock, error) {
+    l, b, err := readUvarint(b)
+    if err != nil {
+        return nil, err
+    }
+    if uint64(len(b)) < l {
+        return nil, ErrMalformed
+    }
+    vc := &VectorClock{Owner: string(b[:l])}
+    b = b[l:]
+
+    vc.Forks, b, err = readUvarint(b)
+    if err != nil {
+        return nil, err
+    }
+    vc.Entries, b, err = readEntries(b)
+    if err != nil {
+        return nil, err
+    }
+    if len(b) != 0 {
+        return nil, ErrMalformed
+    }
+    return vc, nil
+}
+
+// Print a pretty version with the owner first
+func (vc *VectorClock) Print() string {
+    return vc.Owner + vc.Entries.Print()
+}
@@ -0,0 +1,151 @@
+package vector
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// ErrMalformed is returned when decoding bytes that were not produced by Encode
+var ErrMalformed = errors.New("vector: malformed encoding")
+
+// A version vector maps every replica that has ever updated the data to the number of updates it has made.
+// Entries are never removed, which is the growth that Interval Tree Clocks avoid.
+type VersionVector map[string]uint64
+
+func NewVersionVector() VersionVector {
+    return VersionVector{}
+}
+
+// Produce a copy of the version vector
+func (vv VersionVector) Copy() VersionVector {
+    c := make(VersionVector, len(vv))
+    for r, n := range vv {
+        c[r] = n
+    }
+    return c
+}
+
+// Record an update at the replica
+func (vv VersionVector) Increment(replica string) VersionVector {
+    c := vv.Copy()
+    c[replica]++
+    return c
+}
+
+// Pointwise maximum of the two vectors
+func (vv VersionVector) Merge(other VersionVector) VersionVector {
+    c := vv.Copy()
+    for r, n := range other {
+        if n > c[r] {
+            c[r] = n
+        }
+    }
+    return c
+}
+
+// Less or equals - every entry is dominated by the matching entry in other
+func (vv VersionVector) Leq(other VersionVector) bool {
+    for r, n := range vv {
+        if n > other[r] {
+            return false
+        }
+    }
+    return true
+}
+
+// Encode the vector compactly: a count followed by (name, counter) pairs sorted by name
+func (vv VersionVector) Encode() []byte {
+    return appendEntries(nil, vv)
+}
+
+func DecodeVersionVector(b []byte) (VersionVector, error) {
+    vv, rest, err := readEntries(b)
+    if err != nil {
+        return nil, err
+    }
+    if len(rest) != 0 {
+        return nil, ErrMalformed
+    }
+    return vv, nil
+}
+
+// Print a pretty version sorted by replica name
+func (vv VersionVector) Print() string {
+    var sb strings.Builder
+
+    sb.WriteString("{")
+    for i, r := range vv.replicas() {
+        if i > 0 {
+            sb.WriteString(",")
+        }
+        sb.WriteString(fmt.Sprintf("%s:%d", r, vv[r]))
+    }
+    sb.WriteString("}")
+
+    return sb.String()
+}
+
+func (vv VersionVector) replicas() []string {
+    names := make([]string, 0, len(vv))
+    for r := range vv {
+        names = append(names, r)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func appendEntries(b []byte, vv VersionVector) []byte {
+    b = appendUvarint(b, uint64(len(vv)))
+    for _, r := range vv.replicas() {
+        b = appendUvarint(b, uint64(len(r)))
+        b = append(b, r...)
+        b = appendUvarint(b, vv[r])
+    }
+    return b
+}
+
+func readEntries(b []byte) (VersionVector, []byte, error) {
+    count, b, err := readUvarint(b)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    vv := make(VersionVector)
+    for i := uint64(0); i < count; i++ {
+        var l, n uint64
+        l, b, err = readUvarint(b)
+        if err != nil {
+            return nil, nil, err
+        }
+        if uint64(len(b)) < l {
+            return nil, nil, ErrMalformed
+        }
+        r := string(b[:l])
+        b = b[l:]
+
+        n, b, err = readUvarint(b)
+        if err != nil {
+            return nil, nil, err
+        }
+        vv[r] = n
+    }
+
+    return vv, b, nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+    var buf [binary.MaxVarintLen64]byte
+    n := binary.PutUvarint(buf[:], v)
+    return append(b, buf[:n]...)
+}
+
+func readUvarint(b []byte) (uint64, []byte, error) {
+    v, n := binary.Uvarint(b)
+    if n <= 0 {
+        return 0, nil, ErrMalformed
+    }
+    return v, b[n:], nil
+}
@@ -0,0 +1,136 @@
+package vector_test
+
+import (
+	"fmt"
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/itc"
+	"github.com/ziglet.io/go-itc/vector"
+	"math/rand"
+	"testing"
+)
+
+// Fork the seed stamp into n replicas named r0..rn-1
+func forkReplicas(rng *rand.Rand, n int) map[string]*itc.Stamp {
+	stamps := []*itc.Stamp{itc.SeedStamp()}
+	for len(stamps) < n {
+		i := rng.Intn(len(stamps))
+		a, b := stamps[i].Fork()
+		stamps[i] = a
+		stamps = append(stamps, b)
+	}
+
+	replicas := map[string]*itc.Stamp{}
+	for i, s := range stamps {
+		replicas[fmt.Sprintf("r%d", i)] = s
+	}
+	return replicas
+}
+
+func idsOf(replicas map[string]*itc.Stamp) map[string]*itc.Id {
+	ids := map[string]*itc.Id{}
+	for r, s := range replicas {
+		ids[r] = s.Id
+	}
+	return ids
+}
+
+func randomVector(rng *rand.Rand, ids map[string]*itc.Id) vector.VersionVector {
+	vv := vector.NewVersionVector()
+	for r := range ids {
+		if n := rng.Intn(5); n > 0 {
+			vv[r] = uint64(n)
+		}
+	}
+	return vv
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		ids := idsOf(forkReplicas(rng, 1+rng.Intn(8)))
+		vv := randomVector(rng, ids)
+
+		e, err := vector.ToEvent(vv, ids)
+		assert.Nil(err, t)
+		back, err := vector.FromEvent(e, ids)
+		assert.Nil(err, t)
+		assert.True(back.Print() == vv.Print(), t, vv.Print(), back.Print())
+	}
+}
+
+func TestConvertPreservesOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		ids := idsOf(forkReplicas(rng, 1+rng.Intn(8)))
+		vv1 := randomVector(rng, ids)
+		vv2 := randomVector(rng, ids)
+
+		e1, err := vector.ToEvent(vv1, ids)
+		assert.Nil(err, t)
+		e2, err := vector.ToEvent(vv2, ids)
+		assert.Nil(err, t)
+
+		assert.True(vv1.Leq(vv2) == e1.Leq(e2), t, vv1.Print(), vv2.Print())
+		assert.True(vv2.Leq(vv1) == e2.Leq(e1), t, vv1.Print(), vv2.Print())
+	}
+}
+
+// Events recorded through Advance project onto a vector that is never ahead of them
+func TestConvertProjection(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	replicas := forkReplicas(rng, 6)
+	ids := idsOf(replicas)
+
+	event := itc.NewEvent(0)
+	for i := 0; i < 200; i++ {
+		r := fmt.Sprintf("r%d", rng.Intn(len(replicas)))
+		s := itc.NewStamp(replicas[r].Id, replicas[r].Event.Join(event)).Advance()
+		replicas[r] = s
+		event = event.Join(s.Event)
+
+		vv, err := vector.FromEvent(event, ids)
+		assert.Nil(err, t)
+		e, err := vector.ToEvent(vv, ids)
+		assert.Nil(err, t)
+		assert.True(e.Leq(event), t)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	a, b := itc.SeedStamp().Fork()
+	vv := vector.NewVersionVector().Increment("a")
+
+	_, err := vector.ToEvent(vv, map[string]*itc.Id{"b": b.Id})
+	assert.Err(err, t)
+
+	_, err = vector.ToEvent(vv, map[string]*itc.Id{"a": a.Id, "b": itc.NewId(1)})
+	assert.Err(err, t)
+
+	_, err = vector.FromEvent(itc.NewEvent(1), map[string]*itc.Id{"a": itc.NewId(0)})
+	assert.Err(err, t)
+}
+
+func TestConvertUnnormalisedIds(t *testing.T) {
+	zero, one := itc.NewId(0), itc.NewId(1)
+	ids := map[string]*itc.Id{
+		"a": &itc.Id{Left: &itc.Id{Left: zero, Right: zero}, Right: one},
+		"b": &itc.Id{Left: &itc.Id{Left: one, Right: one}, Right: zero},
+		"c": &itc.Id{Left: zero, Right: &itc.Id{Left: zero, Right: zero}},
+	}
+	vv := vector.NewVersionVector().Increment("a").Increment("b").Increment("b")
+
+	// Ids that are not in normal form are still checked and converted without panicking
+	_, err := vector.ToEvent(vv, ids)
+	assert.Err(err, t)
+
+	delete(ids, "c")
+	event, err := vector.ToEvent(vv, ids)
+	assert.Nil(err, t)
+	back, err := vector.FromEvent(event, ids)
+	assert.Nil(err, t)
+	assert.True(back["a"] == 1 && back["b"] == 2, t)
+
+	ids["c"] = &itc.Id{Left: &itc.Id{Left: zero, Right: one}, Right: zero}
+	_, err = vector.ToEvent(vv, ids)
+	assert.Err(err, t)
+}
@@ -0,0 +1,44 @@
+package vector_test
+
+import (
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/vector"
+	"testing"
+)
+
+func TestVectorClockFork(t *testing.T) {
+	a := vector.SeedClock("a").Tick()
+	a, b := a.Fork()
+	a, c := a.Fork()
+
+	assert.True(a.Owner == "a", t)
+	assert.True(b.Owner == "a.0", t)
+	assert.True(c.Owner == "a.1", t)
+
+	b = b.Tick()
+	assert.True(a.Leq(b), t)
+	assert.False(b.Leq(a), t)
+}
+
+func TestVectorClockJoin(t *testing.T) {
+	a, b := vector.SeedClock("a").Fork()
+	a = a.Tick()
+	b = b.Tick()
+
+	assert.False(a.Leq(b), t)
+	assert.False(b.Leq(a), t)
+
+	j := a.Join(b)
+	assert.True(a.Leq(j), t)
+	assert.True(b.Leq(j), t)
+	assert.True(j.Print() == "a{a:2,a.0:1}", t)
+}
+
+func TestVectorClockEncode(t *testing.T) {
+	a, _ := vector.SeedClock("a").Tick().Fork()
+
+	decoded, err := vector.DecodeVectorClock(a.Encode())
+	assert.Nil(err, t)
+	assert.True(decoded.Print() == a.Print(), t)
+	assert.True(decoded.Forks == a.Forks, t)
+}
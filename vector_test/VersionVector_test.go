@@ -0,0 +1,38 @@
+package vector_test
+
+import (
+	"github.com/ipfs/go-ipfs/thirdparty/assert"
+	"github.com/ziglet.io/go-itc/vector"
+	"testing"
+)
+
+func TestVersionVectorIncrement(t *testing.T) {
+	vv := vector.NewVersionVector().Increment("a").Increment("a").Increment("b")
+
+	assert.True(vv["a"] == 2, t)
+	assert.True(vv["b"] == 1, t)
+}
+
+func TestVersionVectorMergeLeq(t *testing.T) {
+	a := vector.NewVersionVector().Increment("a")
+	b := vector.NewVersionVector().Increment("b")
+
+	assert.False(a.Leq(b), t)
+	assert.False(b.Leq(a), t)
+
+	m := a.Merge(b)
+	assert.True(a.Leq(m), t)
+	assert.True(b.Leq(m), t)
+	assert.True(m.Print() == "{a:1,b:1}", t)
+}
+
+func TestVersionVectorEncode(t *testing.T) {
+	vv := vector.NewVersionVector().Increment("a").Increment("bb").Increment("bb")
+
+	decoded, err := vector.DecodeVersionVector(vv.Encode())
+	assert.Nil(err, t)
+	assert.True(decoded.Print() == vv.Print(), t)
+
+	_, err = vector.DecodeVersionVector(vv.Encode()[:3])
+	assert.Err(err, t)
+}